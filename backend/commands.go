@@ -0,0 +1,247 @@
+package main
+
+import (
+	"blog-backend/config"
+	"blog-backend/controllers"
+	"blog-backend/models"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// 子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"serve", "启动HTTP服务器 (默认)", runServe},
+	{"migrate", "数据库结构迁移: status | up [版本] | down [数量]", runMigrate},
+	{"migrate-db", "把数据复制到另一个数据库，如 SQLite 迁移到 PostgreSQL", runMigrateDB},
+	{"create-admin", "创建管理员账户", runCreateAdmin},
+	{"reset-password", "重置用户密码", runResetPassword},
+	{"export", "导出数据或站点归档到文件", runExport},
+	{"import", "从文件导入数据", runImport},
+	{"backup", "立即执行一次备份，保存到 BACKUP_DIR", runBackup},
+	{"reindex", "重新生成文章slug，重新计算点赞数和文章热度", runReindex},
+	{"check", "检查数据完整性，--repair 修复发现的问题", runCheck},
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "用法: blog-backend [命令] [参数]\n\n命令:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-16s%s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(os.Stderr, "\n使用 blog-backend <命令> -h 查看命令的参数")
+}
+
+// 管理命令使用与服务器相同的配置和数据库，连接时执行未执行的结构迁移
+func initDB() {
+	models.InitDBWithConfig(config.AppConfig.DBType, config.AppConfig.GetDSN())
+}
+
+// 读取密码：终端中不回显输入，否则从标准输入读一行，便于在脚本中使用
+func readPassword(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return string(password), err
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("未提供密码")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func runCreateAdmin(args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	username := fs.String("username", "", "用户名")
+	email := fs.String("email", "", "邮箱")
+	password := fs.String("password", "", "密码，不指定时从终端或标准输入读取")
+	fs.Parse(args)
+
+	if *username == "" {
+		fs.Usage()
+		return errors.New("必须指定 --username")
+	}
+	if *password == "" {
+		var err error
+		if *password, err = readPassword("密码: "); err != nil {
+			return err
+		}
+	}
+
+	initDB()
+	admin, err := controllers.CreateAdmin(*username, *email, *password)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已创建管理员 %s (ID %d)\n", admin.Username, admin.ID)
+	return nil
+}
+
+func runResetPassword(args []string) error {
+	fs := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	username := fs.String("username", "", "用户名")
+	password := fs.String("password", "", "新密码，不指定时从终端或标准输入读取")
+	fs.Parse(args)
+
+	if *username == "" {
+		fs.Usage()
+		return errors.New("必须指定 --username")
+	}
+	if *password == "" {
+		var err error
+		if *password, err = readPassword("新密码: "); err != nil {
+			return err
+		}
+	}
+
+	initDB()
+	if err := controllers.ResetUserPassword(*username, *password); err != nil {
+		return err
+	}
+	fmt.Printf("已重置用户 %s 的密码\n", *username)
+	return nil
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	output := fs.String("output", "", "输出文件路径")
+	format := fs.String("format", "", "json、ndjson、tar.gz 或 zip，默认按输出文件扩展名判断")
+	fs.Parse(args)
+
+	if *output == "" {
+		fs.Usage()
+		return errors.New("必须指定 --output")
+	}
+
+	initDB()
+	if err := controllers.ExportToFile(*output, *format); err != nil {
+		return err
+	}
+	fmt.Printf("已导出到 %s\n", *output)
+	return nil
+}
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	file := fs.String("file", "", "导入文件路径 (JSON、NDJSON 或站点归档，可以是加密或签名的文件)")
+	clearExisting := fs.Bool("clear-existing", false, "导入前清除现有数据")
+	mergeMode := fs.Bool("merge-mode", true, "已存在的用户名和标签名复用现有记录")
+	dryRun := fs.Bool("dry-run", false, "只检查并输出报告，不写入数据")
+	preserveIDs := fs.Bool("preserve-ids", false, "保留原始ID，仅能导入到空数据库")
+	passphrase := fs.String("passphrase", "", "解密口令，默认使用 BACKUP_PASSPHRASE")
+	fs.Parse(args)
+
+	if *file == "" {
+		fs.Usage()
+		return errors.New("必须指定 --file")
+	}
+
+	initDB()
+	options := controllers.ImportOptions{
+		ClearExisting: *clearExisting,
+		MergeMode:     *mergeMode,
+		DryRun:        *dryRun,
+		PreserveIDs:   *preserveIDs,
+	}
+	report, results, info, err := controllers.ImportFile(*file, options, *passphrase)
+	if report != nil {
+		summary := map[string]interface{}{"import_info": info, "report": report}
+		if results != nil {
+			summary["results"] = results
+		}
+		out, _ := json.MarshalIndent(summary, "", "  ")
+		fmt.Println(string(out))
+	}
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		fmt.Println("试运行完成，未写入任何数据")
+	} else {
+		fmt.Println("数据导入成功")
+	}
+	return nil
+}
+
+func runBackup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	fs.Parse(args)
+
+	initDB()
+	backup, err := controllers.RunBackup(models.BackupTriggerManual)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("备份完成: %s (%d 字节, SHA256 %s)\n",
+		controllers.BackupFilePath(backup.Filename), backup.Size, backup.Checksum)
+	return nil
+}
+
+func runReindex(args []string) error {
+	fs := flag.NewFlagSet("reindex", flag.ExitOnError)
+	fs.Parse(args)
+
+	initDB()
+	results, err := controllers.Reindex()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已为 %d 篇文章生成slug，修正了 %d 篇文章的点赞数，%d 篇文章有热度\n",
+		results["slugs"], results["likes"], results["trending"])
+	return nil
+}
+
+func runCheck(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	repair := fs.Bool("repair", false, "在一个事务中修复发现的问题")
+	fs.Parse(args)
+
+	initDB()
+	var report *controllers.IntegrityReport
+	var err error
+	if *repair {
+		report, err = controllers.RepairIntegrity()
+	} else {
+		report, err = controllers.CheckIntegrity(models.DB)
+	}
+	if err != nil {
+		return err
+	}
+
+	out, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(out))
+	switch {
+	case report.TotalIssues == 0:
+		fmt.Println("未发现数据完整性问题")
+	case report.Repaired:
+		fmt.Printf("已修复 %d 个问题\n", report.TotalIssues)
+	default:
+		return fmt.Errorf("发现 %d 个问题，使用 --repair 修复", report.TotalIssues)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"log"
 	"os"
 	"strconv"
 )
@@ -19,9 +20,53 @@ type Config struct {
 	ServerPort string
 	JWTSecret  string
 
+	// JWT签名配置
+	JWTAlgorithm string // HS256, RS256, EdDSA
+	JWTKeyDir    string // 非对称密钥目录，文件名(去掉扩展名)即kid
+	JWTActiveKID string // 当前用于签名的kid，为空时取目录中排序第一的私钥
+
 	// 上传配置
 	UploadPath    string
 	MaxUploadSize int64
+	ImportPath    string // 服务器本地导入导出目录，WordPress 媒体文件、Markdown 文章目录等都位于其下
+
+	// 账户配置
+	RegistrationMode         string // open, invite-only, closed
+	AccountDeletionGraceDays int64  // 注销宽限期（天），期满后删除用户数据
+
+	// 邮件配置，未设置SMTPHost时邮件内容只写入日志
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUser     string
+	SMTPPassword string
+	SMTPFrom     string
+	SiteURL      string // 邮件中链接使用的站点地址
+
+	// 自动备份配置
+	BackupSchedule       string // cron 表达式，为空时不自动备份
+	BackupDir            string // 备份文件保存目录
+	BackupFormat         string // json (gzip压缩的数据导出)、archive (包含上传文件的站点归档) 或 database (SQLite快照/SQL数据转储)
+	BackupRetentionCount int64  // 最多保留的成功备份数，0 表示不限制
+	BackupRetentionDays  int64  // 备份保留天数，0 表示不限制
+
+	// 导出和备份的加密与签名配置
+	BackupPassphrase       string // 用口令加密 (age scrypt)
+	BackupAgeRecipients    string // age 公钥 (age1...)，多个用逗号分隔，不能与口令同时使用
+	BackupAgeIdentityFile  string // 导入时解密使用的 age 私钥文件
+	BackupSigningKey       string // HMAC-SHA256 签名密钥，设置后导出的文件都会签名
+	BackupTrustedKeys      string // 额外信任的其他实例的签名密钥，多个用逗号分隔
+	BackupRequireSignature bool   // 只允许导入签名有效的文件
+
+	// 浏览量统计配置
+	ViewFlushInterval    int64  // 浏览量写入数据库的间隔（秒）
+	ViewDedupeWindow     int64  // 同一访客重复浏览只计一次的时间窗口（分钟）
+	ViewIgnoreUserAgents string // 额外忽略的 User-Agent 关键字，多个用逗号分隔，不区分大小写
+
+	// 热门排序配置
+	TrendingWindowDays int64   // 计算热度时统计最近多少天的浏览量和点赞
+	TrendingGravity    float64 // 时间衰减指数，越大旧的访问衰减越快
+	TrendingLikeWeight float64 // 一个点赞相当于多少次浏览
+	TrendingInterval   int64   // 重新计算热度的间隔（分钟）
 
 	// 其他配置
 	Environment string // development, production
@@ -29,6 +74,19 @@ type Config struct {
 
 var AppConfig *Config
 
+// 未设置JWT_SECRET时使用的默认密钥，生产环境禁止使用
+const DefaultJWTSecret = "your-secret-key"
+
+// 热门文章统计天数的上限
+const MaxTrendingWindowDays = 90
+
+// 注册模式
+const (
+	RegistrationOpen       = "open"
+	RegistrationInviteOnly = "invite-only"
+	RegistrationClosed     = "closed"
+)
+
 // 初始化配置
 func InitConfig() {
 	AppConfig = &Config{
@@ -43,15 +101,71 @@ func InitConfig() {
 
 		// 服务器配置
 		ServerPort: getEnv("SERVER_PORT", "8080"),
-		JWTSecret:  getEnv("JWT_SECRET", "your-secret-key"),
+		JWTSecret:  getEnv("JWT_SECRET", DefaultJWTSecret),
+
+		// JWT签名配置
+		JWTAlgorithm: getEnv("JWT_ALGORITHM", "HS256"),
+		JWTKeyDir:    getEnv("JWT_KEY_DIR", "./keys"),
+		JWTActiveKID: getEnv("JWT_ACTIVE_KID", ""),
 
 		// 上传配置
 		UploadPath:    getEnv("UPLOAD_PATH", "./uploads"),
 		MaxUploadSize: getEnvAsInt64("MAX_UPLOAD_SIZE", 10*1024*1024), // 10MB
+		ImportPath:    getEnv("IMPORT_PATH", "./imports"),
+
+		// 账户配置
+		RegistrationMode:         getEnv("REGISTRATION_MODE", RegistrationOpen),
+		AccountDeletionGraceDays: getEnvAsInt64("ACCOUNT_DELETION_GRACE_DAYS", 14),
+
+		// 邮件配置
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUser:     getEnv("SMTP_USER", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", "noreply@blog.com"),
+		SiteURL:      getEnv("SITE_URL", "http://localhost:3000"),
+
+		// 自动备份配置
+		BackupSchedule:       getEnv("BACKUP_SCHEDULE", ""),
+		BackupDir:            getEnv("BACKUP_DIR", "./backups"),
+		BackupFormat:         getEnv("BACKUP_FORMAT", "json"),
+		BackupRetentionCount: getEnvAsInt64("BACKUP_RETENTION_COUNT", 7),
+		BackupRetentionDays:  getEnvAsInt64("BACKUP_RETENTION_DAYS", 30),
+
+		// 导出和备份的加密与签名配置
+		BackupPassphrase:       getEnv("BACKUP_PASSPHRASE", ""),
+		BackupAgeRecipients:    getEnv("BACKUP_AGE_RECIPIENTS", ""),
+		BackupAgeIdentityFile:  getEnv("BACKUP_AGE_IDENTITY_FILE", ""),
+		BackupSigningKey:       getEnv("BACKUP_SIGNING_KEY", ""),
+		BackupTrustedKeys:      getEnv("BACKUP_TRUSTED_KEYS", ""),
+		BackupRequireSignature: getEnvAsBool("BACKUP_REQUIRE_SIGNATURE", false),
+
+		// 浏览量统计配置
+		ViewFlushInterval:    getEnvAsInt64("VIEW_FLUSH_INTERVAL", 10),
+		ViewDedupeWindow:     getEnvAsInt64("VIEW_DEDUPE_WINDOW", 30),
+		ViewIgnoreUserAgents: getEnv("VIEW_IGNORE_USER_AGENTS", ""),
+
+		// 热门排序配置
+		TrendingWindowDays: getEnvAsInt64("TRENDING_WINDOW_DAYS", 7),
+		TrendingGravity:    getEnvAsFloat64("TRENDING_GRAVITY", 1.8),
+		TrendingLikeWeight: getEnvAsFloat64("TRENDING_LIKE_WEIGHT", 5),
+		TrendingInterval:   getEnvAsInt64("TRENDING_INTERVAL", 15),
 
 		// 环境配置
 		Environment: getEnv("ENVIRONMENT", "development"),
 	}
+
+	// 注册模式写错时拒绝启动，避免意外开放注册
+	switch AppConfig.RegistrationMode {
+	case RegistrationOpen, RegistrationInviteOnly, RegistrationClosed:
+	default:
+		log.Fatalf("无效的 REGISTRATION_MODE: %q，可选值为 open、invite-only、closed", AppConfig.RegistrationMode)
+	}
+
+	// 热门接口默认使用该天数，超出范围时所有请求都会失败
+	if days := AppConfig.TrendingWindowDays; days < 1 || days > MaxTrendingWindowDays {
+		log.Fatalf("无效的 TRENDING_WINDOW_DAYS: %d，应为1到%d之间的天数", days, MaxTrendingWindowDays)
+	}
 }
 
 // 获取环境变量，如果不存在则返回默认值
@@ -62,6 +176,16 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// 获取环境变量作为float64
+func getEnvAsFloat64(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 // 获取环境变量作为int64
 func getEnvAsInt64(key string, defaultValue int64) int64 {
 	if value := os.Getenv(key); value != "" {
@@ -72,6 +196,16 @@ func getEnvAsInt64(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+// 获取环境变量作为bool
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 // 获取数据库连接字符串
 func (c *Config) GetDSN() string {
 	switch c.DBType {
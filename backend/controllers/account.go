@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// 个人数据导出结构体
+type UserDataExport struct {
+	Profile       models.User      `json:"profile"`
+	Likes         []UserLikeExport `json:"likes"`
+	AuthoredPosts []UserPostExport `json:"authored_posts"`
+	ExportedAt    time.Time        `json:"exported_at"`
+}
+
+type UserLikeExport struct {
+	PostID    uint   `json:"post_id"`
+	PostTitle string `json:"post_title"`
+}
+
+type UserPostExport struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Published bool      `json:"published"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 导出当前用户的个人数据
+func ExportMyData(c *gin.Context) {
+	userID := c.GetUint("userID")
+	var user models.User
+	if err := models.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	likes := []UserLikeExport{}
+	if err := models.DB.Table("post_likes").
+		Select("post_likes.post_id, posts.title AS post_title").
+		Joins("LEFT JOIN posts ON posts.id = post_likes.post_id").
+		Where("post_likes.user_id = ?", user.ID).
+		Order("post_likes.id").
+		Scan(&likes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取点赞数据失败: " + err.Error()})
+		return
+	}
+
+	posts := []UserPostExport{}
+	if err := models.DB.Model(&models.Post{}).
+		Where("author_id = ?", user.ID).
+		Order("id").
+		Scan(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取文章数据失败: " + err.Error()})
+		return
+	}
+
+	exportData := UserDataExport{
+		Profile:       user,
+		Likes:         likes,
+		AuthoredPosts: posts,
+		ExportedAt:    time.Now(),
+	}
+
+	filename := fmt.Sprintf("%s_data_%s.json", user.Username, time.Now().Format("20060102_150405"))
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.IndentedJSON(http.StatusOK, exportData)
+}
+
+// 注销账户：需要确认密码，宽限期后删除数据
+func DeleteAccount(c *gin.Context) {
+	userID := c.GetUint("userID")
+	var deleteData struct {
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&deleteData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	var user models.User
+	if err := models.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(deleteData.Password)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "密码错误"})
+		return
+	}
+
+	// 不允许注销最后一个管理员
+	if user.UserType == models.UserTypeAdmin {
+		var adminCount int64
+		models.DB.Model(&models.User{}).
+			Where("user_type = ? AND deletion_requested_at IS NULL", models.UserTypeAdmin).
+			Count(&adminCount)
+		if adminCount <= 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "不能注销唯一的管理员账户"})
+			return
+		}
+	}
+
+	now := time.Now()
+	if err := models.DB.Model(&user).Update("deletion_requested_at", &now).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "注销账户失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "账户已申请注销，宽限期内重新登录即可撤销",
+		"purge_at": now.Add(accountDeletionGracePeriod()),
+	})
+}
+
+func accountDeletionGracePeriod() time.Duration {
+	return time.Duration(config.AppConfig.AccountDeletionGraceDays) * 24 * time.Hour
+}
+
+// 彻底删除用户数据：点赞（同步文章点赞数）、文章作者关联和用户记录
+// 头像文件需在事务提交后由调用方删除
+func purgeUser(tx *gorm.DB, user models.User) error {
+	// 按该用户的点赞记录扣减文章点赞数
+	if err := tx.Exec(`UPDATE posts SET likes = likes - (
+		SELECT COUNT(*) FROM post_likes WHERE post_likes.post_id = posts.id AND post_likes.user_id = ?
+	) WHERE id IN (SELECT post_id FROM post_likes WHERE user_id = ?)`, user.ID, user.ID).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("user_id = ?", user.ID).Delete(&models.PostLike{}).Error; err != nil {
+		return err
+	}
+
+	// 文章保留，作者匿名化
+	if err := tx.Model(&models.Post{}).Where("author_id = ?", user.ID).Update("author_id", 0).Error; err != nil {
+		return err
+	}
+
+	return tx.Delete(&user).Error
+}
+
+// 删除用户当前头像的各尺寸文件
+// 只按 user.Avatar 记录的文件删除，不按用户ID匹配：导入的用户会沿用原站点用户ID命名的头像文件
+func removeAvatarFiles(userID uint, avatarURL string) {
+	files := avatarFiles(avatarURL)
+	if len(files) == 0 {
+		return
+	}
+	// 其他用户仍在使用同一头像时保留
+	var count int64
+	models.DB.Model(&models.User{}).Where("avatar = ? AND id <> ?", avatarURL, userID).Count(&count)
+	if count > 0 {
+		return
+	}
+	for _, f := range files {
+		os.Remove(f)
+	}
+}
+
+// 删除宽限期已过的注销账户
+func PurgeDeletedAccounts() (int, error) {
+	cutoff := time.Now().Add(-accountDeletionGracePeriod())
+	var users []models.User
+	if err := models.DB.Where("deletion_requested_at IS NOT NULL AND deletion_requested_at < ?", cutoff).Find(&users).Error; err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	var errs []string
+	for _, user := range users {
+		err := models.DB.Transaction(func(tx *gorm.DB) error {
+			return purgeUser(tx, user)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", user.Username, err))
+			continue
+		}
+		removeAvatarFiles(user.ID, user.Avatar)
+		purged++
+	}
+
+	if len(errs) > 0 {
+		return purged, fmt.Errorf("部分账户删除失败: %s", strings.Join(errs, "; "))
+	}
+	return purged, nil
+}
+
+// 启动后台任务，定期删除过期的注销账户
+func StartAccountPurger(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if n, err := PurgeDeletedAccounts(); err != nil {
+				log.Printf("清理注销账户失败: %v", err)
+			} else if n > 0 {
+				log.Printf("已删除 %d 个注销账户", n)
+			}
+			<-ticker.C
+		}
+	}()
+}
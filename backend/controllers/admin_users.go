@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"blog-backend/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 获取管理操作的目标用户，不允许对自己操作
+func findTargetUser(c *gin.Context) (models.User, bool) {
+	var user models.User
+	if err := models.DB.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return user, false
+	}
+
+	if user.ID == c.GetUint("userID") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不能对自己执行此操作"})
+		return user, false
+	}
+
+	return user, true
+}
+
+// 是否为最后一个管理员
+func isLastAdmin(user models.User) bool {
+	if user.UserType != models.UserTypeAdmin {
+		return false
+	}
+	var count int64
+	models.DB.Model(&models.User{}).Where("user_type = ?", models.UserTypeAdmin).Count(&count)
+	return count <= 1
+}
+
+// 设置用户状态
+func setUserStatus(c *gin.Context, status string) {
+	user, ok := findTargetUser(c)
+	if !ok {
+		return
+	}
+
+	var statusData struct {
+		Reason    string     `json:"reason"`
+		ExpiresAt *time.Time `json:"expires_at"` // 为空表示不自动解除
+	}
+
+	if err := c.ShouldBindJSON(&statusData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if statusData.ExpiresAt != nil && statusData.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "到期时间必须晚于当前时间"})
+		return
+	}
+
+	if isLastAdmin(user) {
+		c.JSON(http.StatusConflict, gin.H{"error": "不能暂停或封禁唯一的管理员"})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"status":            status,
+		"status_reason":     statusData.Reason,
+		"status_expires_at": statusData.ExpiresAt,
+	}
+	if err := models.DB.Model(&user).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新用户状态失败"})
+		return
+	}
+
+	models.DB.First(&user, user.ID)
+	c.JSON(http.StatusOK, user)
+}
+
+// 暂停用户
+func SuspendUser(c *gin.Context) {
+	setUserStatus(c, models.UserStatusSuspended)
+}
+
+// 封禁用户
+func BanUser(c *gin.Context) {
+	setUserStatus(c, models.UserStatusBanned)
+}
+
+// 恢复用户（解除暂停或封禁）
+func ReinstateUser(c *gin.Context) {
+	user, ok := findTargetUser(c)
+	if !ok {
+		return
+	}
+
+	updates := map[string]interface{}{
+		"status":            models.UserStatusActive,
+		"status_reason":     "",
+		"status_expires_at": nil,
+	}
+	if err := models.DB.Model(&user).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新用户状态失败"})
+		return
+	}
+
+	models.DB.First(&user, user.ID)
+	c.JSON(http.StatusOK, user)
+}
+
+// 修改用户类型（提升为管理员或降为普通用户）
+func UpdateUserRole(c *gin.Context) {
+	user, ok := findTargetUser(c)
+	if !ok {
+		return
+	}
+
+	var roleData struct {
+		UserType string `json:"user_type" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&roleData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if roleData.UserType != models.UserTypeAdmin && roleData.UserType != models.UserTypeRegular {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户类型"})
+		return
+	}
+
+	if roleData.UserType == models.UserTypeRegular && isLastAdmin(user) {
+		c.JSON(http.StatusConflict, gin.H{"error": "不能降级唯一的管理员"})
+		return
+	}
+
+	if err := models.DB.Model(&user).Update("user_type", roleData.UserType).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新用户类型失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// 立即删除用户
+func DeleteUser(c *gin.Context) {
+	user, ok := findTargetUser(c)
+	if !ok {
+		return
+	}
+
+	if isLastAdmin(user) {
+		c.JSON(http.StatusConflict, gin.H{"error": "不能删除唯一的管理员"})
+		return
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		return purgeUser(tx, user)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除用户失败: " + err.Error()})
+		return
+	}
+	removeAvatarFiles(user.ID, user.Avatar)
+
+	c.JSON(http.StatusOK, gin.H{"message": "用户删除成功"})
+}
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"blog-backend/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 统计查询最多跨越的天数
+const maxStatRangeDays = 366
+
+// 一段时间内的统计合计
+type StatTotals struct {
+	Views    int64 `json:"views"`
+	Visitors int64 `json:"visitors"` // 每日独立访客数之和
+	Likes    int64 `json:"likes"`
+	Unlikes  int64 `json:"unlikes"`
+}
+
+// 时间序列中的一天
+type DailyStatPoint struct {
+	Day string `json:"day"`
+	StatTotals
+}
+
+// 浏览量最高的文章
+type TopPostStat struct {
+	PostID uint   `json:"post_id"`
+	Title  string `json:"title"`
+	Slug   string `json:"slug"`
+	StatTotals
+}
+
+// 来源域名的浏览量
+type ReferrerStat struct {
+	Domain string `json:"domain"`
+	Views  int64  `json:"views"`
+}
+
+const statSums = `COALESCE(SUM(views), 0) AS views, COALESCE(SUM(visitors), 0) AS visitors,
+	COALESCE(SUM(likes), 0) AS likes, COALESCE(SUM(unlikes), 0) AS unlikes`
+
+// 解析 from、to 参数 (YYYY-MM-DD，包含两端)，默认为最近30天，失败时已写入响应
+func parseStatRange(c *gin.Context) (string, string, bool) {
+	to := time.Now()
+	if s := c.Query("to"); s != "" {
+		t, err := time.ParseInLocation(models.StatDayFormat, s, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "日期格式应为 YYYY-MM-DD"})
+			return "", "", false
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -29)
+	if s := c.Query("from"); s != "" {
+		t, err := time.ParseInLocation(models.StatDayFormat, s, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "日期格式应为 YYYY-MM-DD"})
+			return "", "", false
+		}
+		from = t
+	}
+
+	fromDay, toDay := from.Format(models.StatDayFormat), to.Format(models.StatDayFormat)
+	if fromDay > toDay {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "开始日期不能晚于结束日期"})
+		return "", "", false
+	}
+	if to.Sub(from) > maxStatRangeDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "时间范围不能超过366天"})
+		return "", "", false
+	}
+	return fromDay, toDay, true
+}
+
+// 按天汇总的时间序列，没有数据的日期补零，便于直接绘制图表
+func dailySeries(query *gorm.DB, from, to string) ([]DailyStatPoint, error) {
+	var rows []DailyStatPoint
+	if err := query.Select("day, " + statSums).Group("day").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	byDay := make(map[string]StatTotals, len(rows))
+	for _, row := range rows {
+		byDay[row.Day] = row.StatTotals
+	}
+
+	start, _ := time.ParseInLocation(models.StatDayFormat, from, time.Local)
+	series := []DailyStatPoint{}
+	for d := start; ; d = d.AddDate(0, 0, 1) {
+		day := d.Format(models.StatDayFormat)
+		if day > to {
+			break
+		}
+		series = append(series, DailyStatPoint{Day: day, StatTotals: byDay[day]})
+	}
+	return series, nil
+}
+
+// 来源域名排行
+func topReferrers(query *gorm.DB, limit int) ([]ReferrerStat, error) {
+	referrers := []ReferrerStat{}
+	err := query.Select("domain, SUM(views) AS views").Group("domain").
+		Order("views DESC, domain").Limit(limit).Scan(&referrers).Error
+	return referrers, err
+}
+
+// 排行榜条数，默认10，最多100
+func statLimit(c *gin.Context) int {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	return limit
+}
+
+// 获取全站统计：合计、每日时间序列、热门文章和来源排行 (仅管理员可用)
+func GetAnalytics(c *gin.Context) {
+	// 验证管理员权限
+	if !isAdmin(c) {
+		return
+	}
+
+	from, to, ok := parseStatRange(c)
+	if !ok {
+		return
+	}
+	limit := statLimit(c)
+
+	// 先写入缓冲的浏览量，统计包含最新数据
+	FlushViews()
+
+	daily := func() *gorm.DB {
+		return models.DB.Model(&models.PostDailyStat{}).Where("day BETWEEN ? AND ?", from, to)
+	}
+
+	var totals StatTotals
+	if err := daily().Select(statSums).Scan(&totals).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取统计数据失败"})
+		return
+	}
+
+	series, err := dailySeries(daily(), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取统计数据失败"})
+		return
+	}
+
+	topPosts := []TopPostStat{}
+	if err := models.DB.Table("post_daily_stats AS s").
+		Select("s.post_id, p.title, p.slug, "+
+			"SUM(s.views) AS views, SUM(s.visitors) AS visitors, SUM(s.likes) AS likes, SUM(s.unlikes) AS unlikes").
+		Joins("JOIN posts p ON p.id = s.post_id").
+		Where("s.day BETWEEN ? AND ?", from, to).
+		Group("s.post_id, p.title, p.slug").
+		Order("views DESC, s.post_id").Limit(limit).
+		Scan(&topPosts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取统计数据失败"})
+		return
+	}
+
+	referrers, err := topReferrers(models.DB.Model(&models.PostReferrerStat{}).
+		Where("day BETWEEN ? AND ?", from, to), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取统计数据失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":          from,
+		"to":            to,
+		"totals":        totals,
+		"series":        series,
+		"top_posts":     topPosts,
+		"top_referrers": referrers,
+	})
+}
+
+// 获取单篇文章的统计 (仅管理员可用)
+func GetPostAnalytics(c *gin.Context) {
+	// 验证管理员权限
+	if !isAdmin(c) {
+		return
+	}
+
+	var post models.Post
+	if err := models.DB.Select("id, title, slug, view_count, likes").First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+
+	from, to, ok := parseStatRange(c)
+	if !ok {
+		return
+	}
+
+	// 先写入缓冲的浏览量，统计包含最新数据
+	FlushViews()
+
+	daily := func() *gorm.DB {
+		return models.DB.Model(&models.PostDailyStat{}).
+			Where("post_id = ? AND day BETWEEN ? AND ?", post.ID, from, to)
+	}
+
+	var totals StatTotals
+	if err := daily().Select(statSums).Scan(&totals).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取统计数据失败"})
+		return
+	}
+
+	series, err := dailySeries(daily(), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取统计数据失败"})
+		return
+	}
+
+	referrers, err := topReferrers(models.DB.Model(&models.PostReferrerStat{}).
+		Where("post_id = ? AND day BETWEEN ? AND ?", post.ID, from, to), statLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取统计数据失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"post": gin.H{
+			"id":         post.ID,
+			"title":      post.Title,
+			"slug":       post.Slug,
+			"view_count": post.ViewCount,
+			"likes":      post.Likes,
+		},
+		"from":          from,
+		"to":            to,
+		"totals":        totals,
+		"series":        series,
+		"top_referrers": referrers,
+	})
+}
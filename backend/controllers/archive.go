@@ -0,0 +1,516 @@
+package controllers
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"blog-backend/config"
+	"blog-backend/models"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"gorm.io/gorm"
+)
+
+// 归档中的固定文件名
+const (
+	archiveManifestFile = "manifest.json"
+	archiveDataFile     = "data.json"
+	archiveUploadsDir   = "uploads/"
+)
+
+// 匹配文章内容、封面和头像中引用的上传文件
+var uploadRefPattern = regexp.MustCompile(`/uploads/([A-Za-z0-9._\-/]+)`)
+
+// 归档清单
+type ArchiveManifest struct {
+	Format     string        `json:"format"`
+	Version    string        `json:"version"`
+	ExportedAt time.Time     `json:"exported_at"`
+	DataFile   string        `json:"data_file"`
+	Files      []ArchiveFile `json:"files"`
+	Missing    []string      `json:"missing,omitempty"` // 被引用但上传目录中不存在的文件
+}
+
+type ArchiveFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// 收集所有被引用的上传文件（相对上传目录的路径）
+func collectUploadRefs(db *gorm.DB) ([]string, error) {
+	refs := make(map[string]bool)
+	add := func(text string) {
+		for _, m := range uploadRefPattern.FindAllStringSubmatch(text, -1) {
+			refs[m[1]] = true
+		}
+	}
+
+	var posts []models.Post
+	err := db.Select("id", "content", "cover_image").FindInBatches(&posts, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, post := range posts {
+			add(post.CoverImage)
+			add(post.Content)
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var avatars []string
+	if err := db.Model(&models.User{}).Where("avatar <> ''").Pluck("avatar", &avatars).Error; err != nil {
+		return nil, err
+	}
+	for _, avatar := range avatars {
+		add(avatar)
+		// 同时带上其他尺寸的头像
+		for _, size := range avatarSizes[1:] {
+			add(strings.Replace(avatar, fmt.Sprintf("_%d_", avatarSizes[0]), fmt.Sprintf("_%d_", size), 1))
+		}
+	}
+
+	list := make([]string, 0, len(refs))
+	for ref := range refs {
+		if safeRelPath(ref) != "" {
+			list = append(list, ref)
+		}
+	}
+	sort.Strings(list)
+	return list, nil
+}
+
+// 规范化相对路径，拒绝绝对路径和 ".." 越界
+func safeRelPath(p string) string {
+	cleaned := path.Clean(strings.ReplaceAll(p, "\\", "/"))
+	if cleaned == "." || path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return ""
+	}
+	return cleaned
+}
+
+// 归档写入接口，屏蔽 tar.gz 和 zip 的差异
+type archiveWriter interface {
+	addFile(name string, size int64, modTime time.Time, r io.Reader) error
+	close() error
+}
+
+type tarGzWriter struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func (w *tarGzWriter) addFile(name string, size int64, modTime time.Time, r io.Reader) error {
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: size, ModTime: modTime, Typeflag: tar.TypeReg}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := io.Copy(w.tw, r)
+	return err
+}
+
+func (w *tarGzWriter) close() error {
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
+
+type zipWriter struct {
+	zw *zip.Writer
+}
+
+func (w *zipWriter) addFile(name string, size int64, modTime time.Time, r io.Reader) error {
+	hdr := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime}
+	fw, err := w.zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, r)
+	return err
+}
+
+func (w *zipWriter) close() error {
+	return w.zw.Close()
+}
+
+// 直接写入目录，用于导出到服务器本地目录
+type dirWriter struct {
+	root string
+}
+
+func (w *dirWriter) addFile(name string, size int64, modTime time.Time, r io.Reader) error {
+	rel := safeRelPath(name)
+	if rel == "" {
+		return fmt.Errorf("非法的文件路径: %s", name)
+	}
+	dst := filepath.Join(w.root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, modTime, modTime)
+}
+
+func (w *dirWriter) close() error {
+	return nil
+}
+
+func newArchiveWriter(format string, out io.Writer) archiveWriter {
+	if format == "zip" {
+		return &zipWriter{zw: zip.NewWriter(out)}
+	}
+	gz := gzip.NewWriter(out)
+	return &tarGzWriter{gz: gz, tw: tar.NewWriter(gz)}
+}
+
+// 计算文件大小和SHA256
+func hashFile(p string) (int64, string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// 写出完整站点归档：清单、数据和被引用的上传文件
+func writeSiteArchive(db *gorm.DB, format string, out io.Writer, exportedAt time.Time) error {
+	// 数据先流式写入临时文件，tar需要提前知道大小
+	dataFile, err := os.CreateTemp("", "blog_export_*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dataFile.Name())
+	defer dataFile.Close()
+
+	if err := writeBackupJSON(db, dataFile, exportedAt); err != nil {
+		return err
+	}
+	dataInfo, err := dataFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	refs, err := collectUploadRefs(db)
+	if err != nil {
+		return fmt.Errorf("收集上传文件失败: %w", err)
+	}
+
+	manifest := ArchiveManifest{
+		Format:     "blog-archive",
+		Version:    backupVersion,
+		ExportedAt: exportedAt,
+		DataFile:   archiveDataFile,
+		Files:      []ArchiveFile{},
+	}
+	uploadRoot := config.AppConfig.UploadPath
+	for _, ref := range refs {
+		size, sum, err := hashFile(filepath.Join(uploadRoot, filepath.FromSlash(ref)))
+		if err != nil {
+			manifest.Missing = append(manifest.Missing, ref)
+			continue
+		}
+		manifest.Files = append(manifest.Files, ArchiveFile{Path: ref, Size: size, SHA256: sum})
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	aw := newArchiveWriter(format, out)
+	if err := aw.addFile(archiveManifestFile, int64(len(manifestData)), exportedAt, strings.NewReader(string(manifestData))); err != nil {
+		return err
+	}
+
+	if _, err := dataFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := aw.addFile(archiveDataFile, dataInfo.Size(), exportedAt, dataFile); err != nil {
+		return err
+	}
+
+	for _, file := range manifest.Files {
+		if err := addUploadToArchive(aw, uploadRoot, file); err != nil {
+			return err
+		}
+	}
+
+	return aw.close()
+}
+
+func addUploadToArchive(aw archiveWriter, uploadRoot string, file ArchiveFile) error {
+	f, err := os.Open(filepath.Join(uploadRoot, filepath.FromSlash(file.Path)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return aw.addFile(archiveUploadsDir+file.Path, file.Size, info.ModTime(), io.LimitReader(f, file.Size))
+}
+
+// 导出完整站点归档 (仅管理员可用)，format=tar.gz (默认) 或 zip
+func ExportSiteArchive(c *gin.Context) {
+	// 验证管理员权限
+	if !isAdmin(c) {
+		return
+	}
+
+	format := c.DefaultQuery("format", "tar.gz")
+	var contentType string
+	switch format {
+	case "tar.gz":
+		contentType = "application/gzip"
+	case "zip":
+		contentType = "application/zip"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的归档格式"})
+		return
+	}
+
+	if backupSealed() {
+		contentType = "application/octet-stream"
+	}
+
+	exportedAt := time.Now()
+	filename := fmt.Sprintf("blog_archive_%s.%s%s", exportedAt.Format("20060102_150405"), format, sealedExtension())
+
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+
+	// 按配置加密和签名，加密配置已在启动时检查
+	out, err := sealBackup(c.Writer)
+	if err == nil {
+		err = writeSiteArchive(models.DB, format, out, exportedAt)
+	}
+	if err == nil {
+		err = out.Close()
+	}
+	if err != nil {
+		log.Printf("导出站点归档失败: %v", err)
+		c.Abort()
+	}
+}
+
+// 解压后的导入归档
+type importArchive struct {
+	dir      string // 临时解压目录
+	manifest *ArchiveManifest
+}
+
+func (a *importArchive) uploadsDir() string {
+	return filepath.Join(a.dir, filepath.FromSlash(archiveUploadsDir))
+}
+
+func (a *importArchive) cleanup() {
+	os.RemoveAll(a.dir)
+}
+
+// 把 tar.gz 或 zip 归档解压到目录，拒绝越界路径
+func unpackArchive(dir string, r io.Reader, readerAt io.ReaderAt, size int64, isZip bool) error {
+	extract := func(name string, src io.Reader) error {
+		rel := safeRelPath(name)
+		if rel == "" {
+			return fmt.Errorf("归档中包含非法路径: %s", name)
+		}
+		dst := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		f, err := os.Create(dst)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(f, src)
+		return err
+	}
+
+	if isZip {
+		zr, err := zip.NewReader(readerAt, size)
+		if err != nil {
+			return err
+		}
+		for _, zf := range zr.File {
+			if zf.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := zf.Open()
+			if err != nil {
+				return err
+			}
+			err = extract(zf.Name, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := extract(hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// 解压归档到临时目录
+func extractImportArchive(r io.Reader, readerAt io.ReaderAt, size int64, isZip bool) (*importArchive, error) {
+	dir, err := os.MkdirTemp("", "blog_import_*")
+	if err != nil {
+		return nil, err
+	}
+	archive := &importArchive{dir: dir}
+
+	if err := unpackArchive(dir, r, readerAt, size, isZip); err != nil {
+		archive.cleanup()
+		return nil, err
+	}
+
+	manifestData, err := os.ReadFile(filepath.Join(dir, archiveManifestFile))
+	if err != nil {
+		archive.cleanup()
+		return nil, errors.New("归档中缺少 " + archiveManifestFile)
+	}
+	var manifest ArchiveManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		archive.cleanup()
+		return nil, fmt.Errorf("解析归档清单失败: %w", err)
+	}
+	archive.manifest = &manifest
+
+	// 校验文件完整性
+	for _, file := range manifest.Files {
+		_, sum, err := hashFile(filepath.Join(archive.uploadsDir(), filepath.FromSlash(file.Path)))
+		if err != nil || sum != file.SHA256 {
+			archive.cleanup()
+			return nil, fmt.Errorf("归档文件损坏或缺失: %s", file.Path)
+		}
+	}
+
+	return archive, nil
+}
+
+// 读取归档中的数据文件，由 decodeBackupDocument 校验和升级
+func (a *importArchive) readData() (interface{}, error) {
+	name := a.manifest.DataFile
+	if name == "" {
+		name = archiveDataFile
+	}
+	rel := safeRelPath(name)
+	if rel == "" {
+		return nil, fmt.Errorf("非法的数据文件路径: %s", name)
+	}
+	f, err := os.Open(filepath.Join(a.dir, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return jsonschema.UnmarshalJSON(f)
+}
+
+// 把归档中的上传文件恢复到上传目录，恢复的文件计入 report.Created
+// 目标已存在且内容不同时，overwrite 为 false 则跳过并记录冲突，避免覆盖本站的文件
+func (a *importArchive) restoreUploads(report *EntityReport, overwrite bool) error {
+	uploadRoot := config.AppConfig.UploadPath
+	for _, file := range a.manifest.Files {
+		rel := safeRelPath(file.Path)
+		if rel == "" {
+			report.skip("非法的文件路径: %s", file.Path)
+			continue
+		}
+		src := filepath.Join(a.uploadsDir(), filepath.FromSlash(rel))
+		dst := filepath.Join(uploadRoot, filepath.FromSlash(rel))
+
+		// 目标已存在且内容相同则跳过
+		if _, sum, err := hashFile(dst); err == nil {
+			if sum == file.SHA256 {
+				report.Skipped++
+				continue
+			}
+			if !overwrite {
+				report.skip("文件已存在且内容不同，未覆盖: %s", rel)
+				continue
+			}
+		}
+		if err := copyFile(src, dst); err != nil {
+			return fmt.Errorf("恢复文件 %s 失败: %w", rel, err)
+		}
+		report.Created++
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
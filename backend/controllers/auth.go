@@ -1,12 +1,18 @@
 package controllers
 
 import (
+	"blog-backend/config"
 	"blog-backend/models"
 	"blog-backend/utils"
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 // 用户认证相关
@@ -34,17 +40,45 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	if user.IsBlocked() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":      "账户已被暂停或封禁",
+			"status":     user.Status,
+			"reason":     user.StatusReason,
+			"expires_at": user.StatusExpiresAt,
+		})
+		return
+	}
+
+	// 宽限期内登录撤销账户注销
+	deletionCancelled := false
+	if user.DeletionRequestedAt != nil {
+		if err := models.DB.Model(&user).Update("deletion_requested_at", nil).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "撤销账户注销失败"})
+			return
+		}
+		user.DeletionRequestedAt = nil
+		deletionCancelled = true
+	}
+
 	token, err := utils.GenerateToken(user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成token失败"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"user":  user,
+		"token":              token,
+		"user":               user,
+		"deletion_cancelled": deletionCancelled,
 	})
 }
 
+// 公开JWT验证公钥 (JWKS)
+func GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.GetJWKS())
+}
+
 // 获取当前用户信息
 func GetProfile(c *gin.Context) {
 	userID := c.GetUint("userID")
@@ -58,12 +92,18 @@ func GetProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// 获取注册模式
+func GetRegistrationMode(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"mode": config.AppConfig.RegistrationMode})
+}
+
 // 用户注册
 func Register(c *gin.Context) {
 	var registerData struct {
-		Username string `json:"username" binding:"required"`
-		Password string `json:"password" binding:"required"`
-		Email    string `json:"email"`
+		Username   string `json:"username" binding:"required"`
+		Password   string `json:"password" binding:"required"`
+		Email      string `json:"email"`
+		InviteCode string `json:"invite_code"`
 	}
 
 	if err := c.ShouldBindJSON(&registerData); err != nil {
@@ -71,6 +111,25 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	// 根据注册模式校验
+	var invitation *models.Invitation
+	switch config.AppConfig.RegistrationMode {
+	case config.RegistrationClosed:
+		c.JSON(http.StatusForbidden, gin.H{"error": "暂不开放注册"})
+		return
+	case config.RegistrationInviteOnly:
+		inv, errMsg := validateInvitation(registerData.InviteCode, registerData.Email)
+		if inv == nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": errMsg})
+			return
+		}
+		invitation = inv
+	case config.RegistrationOpen:
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "暂不开放注册"})
+		return
+	}
+
 	// 检查用户名是否已存在
 	var existingUser models.User
 	if err := models.DB.Where("username = ?", registerData.Username).First(&existingUser).Error; err == nil {
@@ -93,7 +152,20 @@ func Register(c *gin.Context) {
 		UserType: models.UserTypeRegular,
 	}
 
-	if err := models.DB.Create(&user).Error; err != nil {
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		if invitation != nil {
+			return redeemInvitation(tx, invitation, user.ID)
+		}
+		return nil
+	})
+	if errors.Is(err, errInvitationUsed) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "邀请码已被使用"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建用户失败"})
 		return
 	}
@@ -187,36 +259,24 @@ func AdminChangeUserPassword(c *gin.Context) {
 		return
 	}
 
-	// 哈希新密码
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(passwordData.NewPassword), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "密码加密失败"})
-		return
-	}
-
-	// 更新密码
-	if err := models.DB.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新密码失败"})
+	// 更新密码，与命令行的 reset-password 共用实现
+	if err := setUserPassword(&user, passwordData.NewPassword); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "用户密码修改成功"})
 }
 
-// 初始化管理员账户
+// 初始化管理员账户，没有任何用户时创建默认管理员 (与命令行的 create-admin 共用实现)
 func InitAdmin() {
 	var count int64
 	models.DB.Model(&models.User{}).Count(&count)
 
 	if count == 0 {
-		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.DefaultCost)
-		admin := models.User{
-			Username: "admin",
-			Password: string(hashedPassword),
-			Email:    "admin@blog.com",
-			UserType: models.UserTypeAdmin,
+		if _, err := CreateAdmin("admin", "admin@blog.com", "admin123"); err != nil {
+			log.Printf("创建默认管理员失败: %v", err)
 		}
-		models.DB.Create(&admin)
 	}
 }
 
@@ -235,12 +295,61 @@ func GetAllUsers(c *gin.Context) {
 		return
 	}
 
-	// 获取所有用户
-	var users []models.User
-	if err := models.DB.Order("id desc").Find(&users).Error; err != nil {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	search := c.Query("search")
+	userType := c.Query("user_type")
+	status := c.Query("status")
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := models.DB.Model(&models.User{})
+
+	// 按用户名或邮箱搜索
+	if search != "" {
+		query = query.Where("username LIKE ? OR email LIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+	if userType != "" {
+		query = query.Where("user_type = ?", userType)
+	}
+	// 暂停或封禁到期后按正常用户处理，与 IsBlocked 一致
+	now := time.Now()
+	switch status {
+	case "":
+	case models.UserStatusActive:
+		query = query.Where("status NOT IN ? OR (status_expires_at IS NOT NULL AND status_expires_at <= ?)",
+			[]string{models.UserStatusSuspended, models.UserStatusBanned}, now)
+	default:
+		query = query.Where("status = ? AND (status_expires_at IS NULL OR status_expires_at > ?)", status, now)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户列表失败"})
+		return
+	}
+
+	users := []models.User{}
+	if err := query.Order("id desc").Offset((page - 1) * limit).Limit(limit).Find(&users).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户列表失败"})
 		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	for i := range users {
+		if !users[i].IsBlocked() {
+			users[i].Status = models.UserStatusActive
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
 }
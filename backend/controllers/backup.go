@@ -2,86 +2,80 @@ package controllers
 
 import (
 	"blog-backend/models"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/santhosh-tekuri/jsonschema/v6"
 )
 
 // 数据导出结构体
 type BackupData struct {
-	Posts      []models.Post     `json:"posts"`
-	Tags       []models.Tag      `json:"tags"`
-	Users      []models.User     `json:"users"`
-	PostLikes  []models.PostLike `json:"post_likes"`
-	ExportedAt time.Time         `json:"exported_at"`
-	Version    string            `json:"version"`
+	Posts       []models.Post       `json:"posts"`
+	Tags        []models.Tag        `json:"tags"`
+	Users       []models.User       `json:"users"`
+	PostLikes   []models.PostLike   `json:"post_likes"`
+	Series      []models.Series     `json:"series"`
+	SeriesPosts []models.SeriesPost `json:"series_posts"`
+	ExportedAt  time.Time           `json:"exported_at"`
+	Version     string              `json:"version"`
 }
 
 // 导出数据 (仅管理员可用)
+// 分批读取并以分块传输直接写入响应，支持 format=json (默认) 或 format=ndjson
 func ExportAllData(c *gin.Context) {
 	// 验证管理员权限
 	if !isAdmin(c) {
 		return
 	}
 
-	var posts []models.Post
-	var tags []models.Tag
-	var users []models.User
-	var postLikes []models.PostLike
-
-	// 获取所有数据 (包含关联关系)
-	if err := models.DB.Preload("Tags").Find(&posts).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取文章数据失败: " + err.Error()})
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "ndjson" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的导出格式"})
 		return
 	}
 
-	if err := models.DB.Preload("Posts").Find(&tags).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取标签数据失败: " + err.Error()})
-		return
-	}
+	exportedAt := time.Now()
+	filename := fmt.Sprintf("blog_export_%s.%s%s", exportedAt.Format("20060102_150405"), format, sealedExtension())
 
-	if err := models.DB.Find(&users).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户数据失败: " + err.Error()})
-		return
+	// 设置响应头，不设置Content-Length以使用分块传输
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("X-Export-Total-Records", strconv.FormatInt(countExportRecords(models.DB), 10))
+	switch {
+	case backupSealed():
+		c.Header("Content-Type", "application/octet-stream")
+	case format == "ndjson":
+		c.Header("Content-Type", "application/x-ndjson")
+	default:
+		c.Header("Content-Type", "application/json")
 	}
-
-	if err := models.DB.Find(&postLikes).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取点赞数据失败: " + err.Error()})
-		return
+	c.Status(http.StatusOK)
+
+	// 按配置加密和签名，加密配置已在启动时检查
+	out, err := sealBackup(c.Writer)
+	if err == nil {
+		if format == "ndjson" {
+			err = writeBackupNDJSON(models.DB, out, exportedAt)
+		} else {
+			err = writeBackupJSON(models.DB, out, exportedAt)
+		}
 	}
-	// 创建导出数据结构
-	exportData := BackupData{
-		Posts:      posts,
-		Tags:       tags,
-		Users:      users,
-		PostLikes:  postLikes,
-		ExportedAt: time.Now(),
-		Version:    "1.0",
+	if err == nil {
+		err = out.Close()
 	}
-
-	// 序列化为JSON
-	jsonData, err := json.MarshalIndent(exportData, "", "  ")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "序列化数据失败: " + err.Error()})
-		return
+		// 响应已开始写出，只能记录日志并中断，客户端会得到不完整的文件
+		log.Printf("导出数据失败: %v", err)
+		c.Abort()
 	}
-
-	// 生成文件名
-	filename := fmt.Sprintf("blog_export_%s.json", time.Now().Format("20060102_150405"))
-
-	// 设置响应头
-	c.Header("Content-Disposition", "attachment; filename="+filename)
-	c.Header("Content-Type", "application/json")
-	c.Header("Content-Length", fmt.Sprintf("%d", len(jsonData)))
-
-	// 返回文件内容
-	c.Data(http.StatusOK, "application/json", jsonData)
 }
 
 // 导入数据 (仅管理员可用)
@@ -99,37 +93,124 @@ func ImportData(c *gin.Context) {
 	}
 	defer file.Close()
 
-	// 检查文件类型
-	if filepath.Ext(header.Filename) != ".json" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "只支持JSON文件"})
+	source, err := openImportSource(file, header.Size, header.Filename, c.PostForm("passphrase"))
+	if err != nil {
+		var schemaErr *backupSchemaError
+		if errors.As(err, &schemaErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": schemaErr.Error(), "details": schemaErr.details})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	defer source.cleanup()
+
+	// 获取导入选项
+	options := parseImportOptions(c)
+
+	executeImport(c, source.data, options, source.info, source.afterCommit(options.ClearExisting))
+}
+
+// 解析后的导入文件
+type importSource struct {
+	data    *BackupData
+	info    gin.H
+	upload  *unsealedBackup
+	archive *importArchive
+}
+
+func (s *importSource) cleanup() {
+	if s.archive != nil {
+		s.archive.cleanup()
+	}
+	s.upload.cleanup()
+}
 
-	// 读取文件内容
-	fileContent, err := io.ReadAll(file)
+// 数据提交成功后再恢复上传文件，overwrite 为 false 时不覆盖内容不同的已有文件
+func (s *importSource) afterCommit(overwrite bool) func(report *ImportReport, results map[string]int) error {
+	if s.archive == nil {
+		return nil
+	}
+	return func(report *ImportReport, results map[string]int) error {
+		report.Files = newEntityReport(len(s.archive.manifest.Files))
+		err := s.archive.restoreUploads(report.Files, overwrite)
+		results["files"] = report.Files.Created
+		return err
+	}
+}
+
+// 读取导入文件：校验签名并解密，按文件名识别格式，校验并升级到当前备份版本
+// 成功时调用方负责 cleanup
+func openImportSource(r io.ReaderAt, size int64, filename, passphrase string) (*importSource, error) {
+	// 签名和加密都按文件内容识别
+	upload, err := unsealBackup(r, size, strings.ToLower(filename), passphrase)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取文件内容失败: " + err.Error()})
-		return
+		return nil, err
 	}
-	// 解析JSON数据
-	var importData BackupData
-	if err := json.Unmarshal(fileContent, &importData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "解析JSON文件失败: " + err.Error()})
-		return
+	source := &importSource{upload: upload}
+
+	// 检查文件类型并读取为通用文档，数字保持原样以免ID丢失精度
+	var doc interface{}
+	name := upload.name
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"), strings.HasSuffix(name, ".zip"):
+		source.archive, err = extractImportArchive(upload.source, upload.source, upload.size, strings.HasSuffix(name, ".zip"))
+		if err != nil {
+			source.cleanup()
+			return nil, fmt.Errorf("解析归档文件失败: %w", err)
+		}
+		doc, err = source.archive.readData()
+		if err != nil {
+			err = fmt.Errorf("解析归档数据失败: %w", err)
+		}
+	case filepath.Ext(name) == ".json":
+		doc, err = jsonschema.UnmarshalJSON(upload.source)
+		if err != nil {
+			err = fmt.Errorf("解析JSON文件失败: %w", err)
+		}
+	case filepath.Ext(name) == ".ndjson", filepath.Ext(name) == ".jsonl":
+		doc, err = readBackupNDJSON(upload.source)
+		if err != nil {
+			err = fmt.Errorf("解析NDJSON文件失败: %w", err)
+		}
+	default:
+		err = errors.New("只支持JSON、NDJSON或站点归档(.tar.gz/.zip)文件")
+	}
+	if err != nil {
+		source.cleanup()
+		return nil, err
 	}
 
-	// 获取导入选项
-	var options struct {
-		ClearExisting bool `json:"clear_existing"` // 是否清除现有数据
-		MergeMode     bool `json:"merge_mode"`     // 是否合并模式
+	// 按备份版本的格式定义校验，旧版本升级到当前版本
+	data, sourceVersion, err := decodeBackupDocument(doc)
+	if err != nil {
+		source.cleanup()
+		return nil, err
 	}
 
-	if err := c.ShouldBind(&options); err != nil {
-		// 如果没有提供选项，使用默认值
-		options.ClearExisting = false
-		options.MergeMode = true
+	source.data = data
+	source.info = gin.H{
+		"exported_at": data.ExportedAt,
+		"version":     sourceVersion,
+		"upgraded":    sourceVersion != backupVersion,
+		"signed":      upload.signed,
+		"encrypted":   upload.encrypted,
+		"total_records": len(data.Posts) + len(data.Tags) +
+			len(data.Users) + len(data.PostLikes) + len(data.Series) + len(data.SeriesPosts),
 	}
+	return source, nil
+}
 
+// 数据已提交但后续操作失败
+type afterCommitError struct {
+	err error
+}
+
+func (e *afterCommitError) Error() string { return "数据已导入，但" + e.err.Error() }
+
+// 在事务中执行导入，试运行时回滚并只返回报告
+// afterCommit 在数据提交后执行，用于恢复上传文件等无法回滚的操作
+func importBackupData(data *BackupData, options ImportOptions, afterCommit func(report *ImportReport, results map[string]int) error) (*ImportReport, map[string]int, error) {
 	// 开始事务
 	tx := models.DB.Begin()
 	defer func() {
@@ -138,168 +219,111 @@ func ImportData(c *gin.Context) {
 		}
 	}()
 
-	// 如果选择清除现有数据
-	if options.ClearExisting {
-		// 删除关联表数据
-		if err := tx.Exec("DELETE FROM post_tags").Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "清除文章标签关联失败: " + err.Error()})
-			return
-		}
-
-		// 删除主表数据
-		tables := []interface{}{&models.PostLike{}, &models.Post{}, &models.Tag{}, &models.User{}}
-		for _, table := range tables {
-			if err := tx.Delete(table, "1 = 1").Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "清除现有数据失败: " + err.Error()})
-				return
-			}
-		}
+	report, err := runImport(tx, data, options)
+	if err != nil {
+		tx.Rollback()
+		return report, nil, err
 	}
 
-	// 导入数据
-	importResults := make(map[string]int)
-
-	// 导入用户
-	for _, user := range importData.Users {
-		user.ID = 0 // 重置ID，让数据库自动分配
-		if options.MergeMode {
-			// 检查用户名是否已存在
-			var existingUser models.User
-			if err := tx.Where("username = ?", user.Username).First(&existingUser).Error; err == nil {
-				continue // 用户已存在，跳过
-			}
-		}
-		if err := tx.Create(&user).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "导入用户数据失败: " + err.Error()})
-			return
-		}
-		importResults["users"]++
-	}
-
-	// 导入标签
-	tagMapping := make(map[uint]uint) // 旧ID -> 新ID
-	for _, tag := range importData.Tags {
-		oldID := tag.ID
-		tag.ID = 0
-		tag.Posts = nil // 清除关联，稍后重建
-
-		if options.MergeMode {
-			// 检查标签名是否已存在
-			var existingTag models.Tag
-			if err := tx.Where("name = ?", tag.Name).First(&existingTag).Error; err == nil {
-				tagMapping[oldID] = existingTag.ID
-				continue
-			}
-		}
-
-		if err := tx.Create(&tag).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "导入标签数据失败: " + err.Error()})
-			return
-		}
-		tagMapping[oldID] = tag.ID
-		importResults["tags"]++
-	}
-
-	// 导入文章
-	for _, post := range importData.Posts {
-		// 处理标签关联
-		var newTags []models.Tag
-		for _, tag := range post.Tags {
-			if newTagID, exists := tagMapping[tag.ID]; exists {
-				var newTag models.Tag
-				if err := tx.First(&newTag, newTagID).Error; err == nil {
-					newTags = append(newTags, newTag)
-				}
-			}
-		}
+	// 试运行：回滚并返回报告
+	if options.DryRun {
+		tx.Rollback()
+		return report, nil, nil
+	}
 
-		post.ID = 0
-		post.Tags = newTags
+	// 提交事务
+	if err := tx.Commit().Error; err != nil {
+		return report, nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+	invalidateRelatedPosts()
 
-		if err := tx.Create(&post).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "导入文章数据失败: " + err.Error()})
-			return
+	results := report.results()
+	if afterCommit != nil {
+		if err := afterCommit(report, results); err != nil {
+			return report, results, &afterCommitError{err: err}
 		}
-		importResults["posts"]++
 	}
+	return report, results, nil
+}
 
-	// 导入点赞数据
-	for _, postLike := range importData.PostLikes {
-		postLike.ID = 0
-		if err := tx.Create(&postLike).Error; err != nil {
-			// 点赞数据可能会有重复，忽略错误
-			continue
-		}
-		importResults["post_likes"]++
+// 执行导入并写出响应
+func executeImport(c *gin.Context, data *BackupData, options ImportOptions, info gin.H, afterCommit func(report *ImportReport, results map[string]int) error) {
+	report, results, err := importBackupData(data, options, afterCommit)
+
+	var importErr *importError
+	var commitErr *afterCommitError
+	switch {
+	case errors.As(err, &commitErr):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "results": results})
+		return
+	case errors.As(err, &importErr):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "report": report})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "report": report})
+		return
 	}
 
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "提交事务失败: " + err.Error()})
+	if options.DryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"message":     "试运行完成，未写入任何数据",
+			"report":      report,
+			"import_info": info,
+		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "数据导入成功",
-		"results": importResults,
-		"import_info": gin.H{
-			"exported_at": importData.ExportedAt,
-			"version":     importData.Version,
-			"total_records": len(importData.Posts) + len(importData.Tags) +
-				len(importData.Users) + len(importData.PostLikes),
-		},
+		"message":     "数据导入成功",
+		"results":     results,
+		"report":      report,
+		"import_info": info,
 	})
 }
 
-// 备份数据库文件 (仅管理员可用，仅适用于SQLite)
+// 备份数据库 (仅管理员可用)，SQLite 返回数据库快照，MySQL/PostgreSQL 返回SQL数据转储
 func BackupDatabase(c *gin.Context) {
 	// 验证管理员权限
 	if !isAdmin(c) {
 		return
 	}
 
-	// 这里假设使用SQLite，实际项目中应该根据配置判断
-	dbPath := "blog.db"
-
-	// 检查数据库文件是否存在
-	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "数据库文件不存在"})
+	// SQLite 生成一致性快照，MySQL/PostgreSQL 生成SQL数据转储
+	path, err := createDatabaseBackup()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "备份数据库失败: " + err.Error()})
 		return
 	}
+	defer os.RemoveAll(filepath.Dir(path))
 
 	// 生成备份文件名
-	backupName := fmt.Sprintf("blog_backup_%s.db", time.Now().Format("20060102_150405"))
-
-	// 读取数据库文件
-	dbFile, err := os.Open(dbPath)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "打开数据库文件失败: " + err.Error()})
+	backupName := fmt.Sprintf("blog_backup_%s%s", time.Now().Format("20060102_150405"), filepath.Ext(path))
+	if !backupSealed() {
+		c.FileAttachment(path, backupName)
 		return
 	}
-	defer dbFile.Close()
 
-	// 获取文件信息
-	fileInfo, err := dbFile.Stat()
+	// 按配置加密和签名后输出
+	src, err := os.Open(path)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取文件信息失败: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取备份文件失败: " + err.Error()})
 		return
 	}
+	defer src.Close()
 
-	// 设置响应头
-	c.Header("Content-Disposition", "attachment; filename="+backupName)
+	c.Header("Content-Disposition", "attachment; filename="+backupName+sealedExtension())
 	c.Header("Content-Type", "application/octet-stream")
-	c.Header("Content-Length", fmt.Sprintf("%d", fileInfo.Size()))
-
-	// 复制文件内容到响应
-	_, err = io.Copy(c.Writer, dbFile)
+	c.Status(http.StatusOK)
+	out, err := sealBackup(c.Writer)
+	if err == nil {
+		_, err = io.Copy(out, src)
+	}
+	if err == nil {
+		err = out.Close()
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "复制文件失败: " + err.Error()})
-		return
+		log.Printf("备份数据库失败: %v", err)
+		c.Abort()
 	}
 }
 
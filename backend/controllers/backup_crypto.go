@@ -0,0 +1,326 @@
+package controllers
+
+import (
+	"blog-backend/config"
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// 签名文件格式：头部一行标识，然后是原始内容，最后是固定长度的签名行
+// 签名是对头部和内容计算的 HMAC-SHA256，先加密再签名，校验时无需解密
+const (
+	signedBackupHeader  = "blog-backup-signed/v1\n"
+	signedBackupTrailer = "\nhmac-sha256:"
+	signedBackupSuffix  = ".signed"
+	encryptedSuffix     = ".age"
+	ageHeader           = "age-encryption.org/v1\n"
+)
+
+// 签名行的总长度：前缀 + 64位十六进制 + 换行
+var signedTrailerLen = int64(len(signedBackupTrailer) + sha256.Size*2 + 1)
+
+var (
+	errBackupSignatureInvalid = errors.New("备份文件签名无效，文件可能被篡改或来自不受信任的实例")
+	errBackupUnsigned         = errors.New("备份文件未签名，当前配置只允许导入签名有效的文件")
+	errBackupNoSigningKey     = errors.New("备份文件已签名，但未配置签名密钥，无法校验")
+	errBackupNoDecryptKey     = errors.New("备份文件已加密，但未配置解密口令或私钥")
+)
+
+func splitConfigList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// 导出时使用的加密接收者，未配置加密时返回nil
+func backupRecipients() ([]age.Recipient, error) {
+	cfg := config.AppConfig
+	keys := splitConfigList(cfg.BackupAgeRecipients)
+	if len(keys) > 0 && cfg.BackupPassphrase != "" {
+		return nil, errors.New("BACKUP_PASSPHRASE 和 BACKUP_AGE_RECIPIENTS 不能同时设置")
+	}
+
+	if cfg.BackupPassphrase != "" {
+		r, err := age.NewScryptRecipient(cfg.BackupPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Recipient{r}, nil
+	}
+
+	var recipients []age.Recipient
+	for _, key := range keys {
+		r, err := age.ParseX25519Recipient(key)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 age 公钥 %q: %w", key, err)
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, nil
+}
+
+// 导入时可用的解密身份，passphrase 为上传时额外提供的口令
+func backupIdentities(passphrase string) ([]age.Identity, error) {
+	cfg := config.AppConfig
+	var identities []age.Identity
+	for _, p := range []string{passphrase, cfg.BackupPassphrase} {
+		if p == "" {
+			continue
+		}
+		id, err := age.NewScryptIdentity(p)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, id)
+	}
+
+	if cfg.BackupAgeIdentityFile != "" {
+		f, err := os.Open(cfg.BackupAgeIdentityFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 age 私钥文件失败: %w", err)
+		}
+		defer f.Close()
+		ids, err := age.ParseIdentities(f)
+		if err != nil {
+			return nil, fmt.Errorf("解析 age 私钥文件失败: %w", err)
+		}
+		identities = append(identities, ids...)
+	}
+	return identities, nil
+}
+
+// 检查加密配置，启动时调用以尽早发现错误
+func CheckBackupProtection() error {
+	if _, err := backupRecipients(); err != nil {
+		return err
+	}
+	_, err := backupIdentities("")
+	return err
+}
+
+// 导出文件按配置加密和签名后追加的扩展名
+func sealedExtension() string {
+	ext := ""
+	if recipients, _ := backupRecipients(); len(recipients) > 0 {
+		ext += encryptedSuffix
+	}
+	if config.AppConfig.BackupSigningKey != "" {
+		ext += signedBackupSuffix
+	}
+	return ext
+}
+
+// 是否需要加密或签名
+func backupSealed() bool {
+	return sealedExtension() != ""
+}
+
+// 写出时计算签名，关闭时追加签名行
+type signingWriter struct {
+	w   io.Writer
+	mac hash.Hash
+}
+
+func newSigningWriter(w io.Writer, key string) (*signingWriter, error) {
+	sw := &signingWriter{w: w, mac: hmac.New(sha256.New, []byte(key))}
+	if _, err := sw.Write([]byte(signedBackupHeader)); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+func (sw *signingWriter) Write(p []byte) (int, error) {
+	sw.mac.Write(p)
+	return sw.w.Write(p)
+}
+
+func (sw *signingWriter) Close() error {
+	_, err := io.WriteString(sw.w, signedBackupTrailer+hex.EncodeToString(sw.mac.Sum(nil))+"\n")
+	return err
+}
+
+// 多层写入器按从内到外的顺序关闭
+type sealedWriter struct {
+	io.Writer
+	closers []io.Closer
+}
+
+func (s *sealedWriter) Close() error {
+	for _, c := range s.closers {
+		if err := c.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 按配置包装导出的输出：先加密再签名，必须调用 Close 才会写出完整文件
+func sealBackup(out io.Writer) (io.WriteCloser, error) {
+	recipients, err := backupRecipients()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := &sealedWriter{Writer: out}
+	if key := config.AppConfig.BackupSigningKey; key != "" {
+		sw, err := newSigningWriter(out, key)
+		if err != nil {
+			return nil, err
+		}
+		sealed.Writer = sw
+		sealed.closers = append(sealed.closers, sw)
+	}
+	if len(recipients) > 0 {
+		ew, err := age.Encrypt(sealed.Writer, recipients...)
+		if err != nil {
+			return nil, fmt.Errorf("加密失败: %w", err)
+		}
+		sealed.Writer = ew
+		sealed.closers = append([]io.Closer{ew}, sealed.closers...)
+	}
+	return sealed, nil
+}
+
+// 可随机读取的备份内容，zip归档需要 ReaderAt
+type backupSource interface {
+	io.Reader
+	io.ReaderAt
+}
+
+// 解开签名和加密后的上传文件
+type unsealedBackup struct {
+	source    backupSource
+	size      int64
+	name      string // 去掉 .signed/.age 后的文件名，用于判断内容格式
+	signed    bool
+	encrypted bool
+	tempFile  *os.File
+}
+
+func (u *unsealedBackup) cleanup() {
+	if u.tempFile != nil {
+		u.tempFile.Close()
+		os.Remove(u.tempFile.Name())
+	}
+}
+
+func hasPrefixAt(r io.ReaderAt, size int64, prefix string) bool {
+	if size < int64(len(prefix)) {
+		return false
+	}
+	buf := make([]byte, len(prefix))
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		return false
+	}
+	return string(buf) == prefix
+}
+
+// 用签名密钥校验签名，成功时返回签名内容
+func verifyBackupSignature(r io.ReaderAt, size int64) (*io.SectionReader, error) {
+	keys := splitConfigList(config.AppConfig.BackupTrustedKeys)
+	if key := config.AppConfig.BackupSigningKey; key != "" {
+		keys = append([]string{key}, keys...)
+	}
+	if len(keys) == 0 {
+		return nil, errBackupNoSigningKey
+	}
+
+	bodyLen := size - signedTrailerLen
+	if bodyLen < int64(len(signedBackupHeader)) {
+		return nil, errBackupSignatureInvalid
+	}
+	trailer := make([]byte, signedTrailerLen)
+	if _, err := r.ReadAt(trailer, bodyLen); err != nil {
+		return nil, err
+	}
+	hexSum, ok := strings.CutPrefix(strings.TrimSuffix(string(trailer), "\n"), signedBackupTrailer)
+	if !ok {
+		return nil, errBackupSignatureInvalid
+	}
+	expected, err := hex.DecodeString(hexSum)
+	if err != nil {
+		return nil, errBackupSignatureInvalid
+	}
+
+	macs := make([]hash.Hash, len(keys))
+	writers := make([]io.Writer, len(keys))
+	for i, key := range keys {
+		macs[i] = hmac.New(sha256.New, []byte(key))
+		writers[i] = macs[i]
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), io.NewSectionReader(r, 0, bodyLen)); err != nil {
+		return nil, err
+	}
+	for _, mac := range macs {
+		if hmac.Equal(mac.Sum(nil), expected) {
+			headerLen := int64(len(signedBackupHeader))
+			return io.NewSectionReader(r, headerLen, bodyLen-headerLen), nil
+		}
+	}
+	return nil, errBackupSignatureInvalid
+}
+
+// 按内容识别并解开签名和加密，未加密的文件原样返回
+// 解密后的内容写入临时文件，调用方负责 cleanup
+func unsealBackup(r io.ReaderAt, size int64, name, passphrase string) (*unsealedBackup, error) {
+	u := &unsealedBackup{source: io.NewSectionReader(r, 0, size), size: size, name: name}
+	u.name = strings.TrimSuffix(u.name, signedBackupSuffix)
+
+	if hasPrefixAt(r, size, signedBackupHeader) {
+		payload, err := verifyBackupSignature(r, size)
+		if err != nil {
+			return nil, err
+		}
+		u.source, u.size, u.signed = payload, payload.Size(), true
+	} else if config.AppConfig.BackupRequireSignature {
+		return nil, errBackupUnsigned
+	}
+
+	u.name = strings.TrimSuffix(u.name, encryptedSuffix)
+	if !hasPrefixAt(u.source, u.size, ageHeader) {
+		return u, nil
+	}
+
+	identities, err := backupIdentities(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(identities) == 0 {
+		return nil, errBackupNoDecryptKey
+	}
+	dr, err := age.Decrypt(bufio.NewReader(io.NewSectionReader(u.source, 0, u.size)), identities...)
+	if err != nil {
+		return nil, fmt.Errorf("解密备份文件失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp("", "blog_import_*")
+	if err != nil {
+		return nil, err
+	}
+	u.tempFile = tmp
+	size, err = io.Copy(tmp, dr)
+	if err != nil {
+		u.cleanup()
+		return nil, fmt.Errorf("解密备份文件失败: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		u.cleanup()
+		return nil, err
+	}
+	u.source, u.size, u.encrypted = tmp, size, true
+	return u, nil
+}
@@ -0,0 +1,294 @@
+package controllers
+
+import (
+	"blog-backend/models"
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// 当前备份格式版本
+// 修改导出结构时递增版本号，新增对应的 JSON Schema，并在 backupUpgrades 中登记上一版本的升级函数
+const backupVersion = "2.2"
+
+// 每个版本的备份格式定义
+//
+//go:embed schemas/backup-*.schema.json
+var backupSchemaFS embed.FS
+
+// 备份格式升级步骤，把文档从某个版本原地升级到下一个版本
+type backupUpgrade struct {
+	to      string
+	upgrade func(doc map[string]interface{}) error
+}
+
+// 旧版本的升级链：版本 -> 升级到的下一版本
+var backupUpgrades = map[string]backupUpgrade{
+	"1.0": {to: "2.0", upgrade: upgradeBackupV1},
+	"2.0": {to: "2.1", upgrade: upgradeBackupV20},
+	"2.1": {to: "2.2", upgrade: upgradeBackupV21},
+}
+
+// 编译后的 JSON Schema，按版本缓存
+var (
+	backupSchemasOnce sync.Once
+	backupSchemas     map[string]*jsonschema.Schema
+	backupSchemasErr  error
+)
+
+var schemaErrorPrinter = message.NewPrinter(language.English)
+
+// 备份数据不符合格式定义
+type backupSchemaError struct {
+	version string
+	details []string
+}
+
+func (e *backupSchemaError) Error() string {
+	return fmt.Sprintf("备份数据不符合 %s 版本格式", e.version)
+}
+
+// 支持导入的所有备份版本
+func backupSchemaVersions() []string {
+	versions := []string{backupVersion}
+	for version := range backupUpgrades {
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+func backupSchemaURL(version string) string {
+	return "https://blog-backend/schemas/backup-" + version + ".schema.json"
+}
+
+func readBackupSchema(version string) ([]byte, error) {
+	return backupSchemaFS.ReadFile("schemas/backup-" + version + ".schema.json")
+}
+
+// 编译所有版本的 JSON Schema
+func loadBackupSchemas() (map[string]*jsonschema.Schema, error) {
+	backupSchemasOnce.Do(func() {
+		compiler := jsonschema.NewCompiler()
+		for _, version := range backupSchemaVersions() {
+			raw, err := readBackupSchema(version)
+			if err != nil {
+				backupSchemasErr = fmt.Errorf("缺少 %s 版本的备份格式定义: %w", version, err)
+				return
+			}
+			doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+			if err != nil {
+				backupSchemasErr = fmt.Errorf("解析 %s 版本的备份格式定义失败: %w", version, err)
+				return
+			}
+			if err := compiler.AddResource(backupSchemaURL(version), doc); err != nil {
+				backupSchemasErr = err
+				return
+			}
+		}
+
+		schemas := make(map[string]*jsonschema.Schema)
+		for _, version := range backupSchemaVersions() {
+			schema, err := compiler.Compile(backupSchemaURL(version))
+			if err != nil {
+				backupSchemasErr = fmt.Errorf("编译 %s 版本的备份格式定义失败: %w", version, err)
+				return
+			}
+			schemas[version] = schema
+		}
+		backupSchemas = schemas
+	})
+	return backupSchemas, backupSchemasErr
+}
+
+// 解析 "主版本.次版本" 格式的版本号
+func parseBackupVersion(version string) (major, minor int, ok bool) {
+	parts := strings.Split(version, ".")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	major, err1 := strconv.Atoi(parts[0])
+	minor, err2 := strconv.Atoi(parts[1])
+	return major, minor, err1 == nil && err2 == nil
+}
+
+// 检查备份版本是否可以导入
+func checkBackupVersion(version string) error {
+	if version == backupVersion {
+		return nil
+	}
+	if _, ok := backupUpgrades[version]; ok {
+		return nil
+	}
+
+	major, minor, ok := parseBackupVersion(version)
+	curMajor, curMinor, _ := parseBackupVersion(backupVersion)
+	if ok && (major > curMajor || (major == curMajor && minor > curMinor)) {
+		return fmt.Errorf("备份文件版本 %s 高于当前支持的版本 %s，请先升级博客程序再导入", version, backupVersion)
+	}
+	return fmt.Errorf("不支持的备份文件版本: %s", version)
+}
+
+// 按指定版本的格式定义校验文档
+func validateBackupDocument(version string, doc map[string]interface{}) error {
+	schemas, err := loadBackupSchemas()
+	if err != nil {
+		return err
+	}
+
+	err = schemas[version].Validate(doc)
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		details := []string{}
+		collectSchemaErrors(validationErr, &details)
+		return &backupSchemaError{version: version, details: details}
+	}
+	return err
+}
+
+// 收集最底层的校验错误，格式为 "/数据路径: 错误说明"
+func collectSchemaErrors(err *jsonschema.ValidationError, details *[]string) {
+	if len(err.Causes) == 0 {
+		location := "/" + strings.Join(err.InstanceLocation, "/")
+		*details = append(*details, location+": "+err.ErrorKind.LocalizedString(schemaErrorPrinter))
+		return
+	}
+	for _, cause := range err.Causes {
+		collectSchemaErrors(cause, details)
+	}
+}
+
+// 校验备份文档并升级到当前版本，返回解析后的数据和文件原始版本
+// doc 需由 jsonschema.UnmarshalJSON 解析，数字保持为 json.Number
+func decodeBackupDocument(doc interface{}) (*BackupData, string, error) {
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, "", errors.New("备份数据必须是JSON对象")
+	}
+
+	version, _ := obj["version"].(string)
+	if version == "" {
+		return nil, "", errors.New("备份文件缺少版本号")
+	}
+	if err := checkBackupVersion(version); err != nil {
+		return nil, version, err
+	}
+
+	// 每一步升级前后都按对应版本的格式校验
+	sourceVersion := version
+	for {
+		if err := validateBackupDocument(version, obj); err != nil {
+			return nil, sourceVersion, err
+		}
+		if version == backupVersion {
+			break
+		}
+		step := backupUpgrades[version]
+		if err := step.upgrade(obj); err != nil {
+			return nil, sourceVersion, fmt.Errorf("备份从 %s 升级到 %s 失败: %w", version, step.to, err)
+		}
+		version = step.to
+		obj["version"] = version
+	}
+
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return nil, sourceVersion, err
+	}
+	var data BackupData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, sourceVersion, err
+	}
+	return &data, sourceVersion, nil
+}
+
+// 取出数组中的对象元素
+func backupObjects(v interface{}) []map[string]interface{} {
+	items, _ := v.([]interface{})
+	objects := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if obj, ok := item.(map[string]interface{}); ok {
+			objects = append(objects, obj)
+		}
+	}
+	return objects
+}
+
+// 1.0 -> 2.0
+// 1.0 的标签中嵌套完整文章，文章没有作者，用户没有账户状态
+func upgradeBackupV1(doc map[string]interface{}) error {
+	for _, key := range []string{"posts", "tags", "users", "post_likes"} {
+		if doc[key] == nil {
+			doc[key] = []interface{}{}
+		}
+	}
+
+	// 文章与标签的关联以 posts[].tags 为准
+	for _, tag := range backupObjects(doc["tags"]) {
+		delete(tag, "posts")
+	}
+
+	for _, post := range backupObjects(doc["posts"]) {
+		if _, ok := post["author_id"]; !ok {
+			post["author_id"] = json.Number("0")
+		}
+		for _, tag := range backupObjects(post["tags"]) {
+			delete(tag, "posts")
+		}
+	}
+
+	for _, user := range backupObjects(doc["users"]) {
+		if userType, _ := user["user_type"].(string); userType == "" {
+			user["user_type"] = models.UserTypeRegular
+		}
+		if _, ok := user["status"]; !ok {
+			user["status"] = models.UserStatusActive
+		}
+	}
+	return nil
+}
+
+// 2.0 -> 2.1
+// 2.0 的文章没有slug，导入时根据标题生成，文档无需转换
+func upgradeBackupV20(doc map[string]interface{}) error {
+	return nil
+}
+
+// 2.1 -> 2.2
+// 2.1 没有系列，补充空的 series 和 series_posts
+func upgradeBackupV21(doc map[string]interface{}) error {
+	for _, key := range []string{"series", "series_posts"} {
+		if doc[key] == nil {
+			doc[key] = []interface{}{}
+		}
+	}
+	return nil
+}
+
+// 获取备份格式的 JSON Schema，默认返回当前版本
+func GetBackupSchema(c *gin.Context) {
+	version := c.DefaultQuery("version", backupVersion)
+	if err := checkBackupVersion(version); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "current_version": backupVersion})
+		return
+	}
+
+	raw, err := readBackupSchema(version)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取备份格式定义失败"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Data(http.StatusOK, "application/schema+json", raw)
+}
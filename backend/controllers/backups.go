@@ -0,0 +1,398 @@
+package controllers
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// 同一时间只允许一个备份运行
+var backupMutex sync.Mutex
+
+// 自动备份的定时任务，未启用时为nil
+var backupScheduler *cron.Cron
+
+var errBackupRunning = errors.New("已有备份正在进行")
+
+// 备份文件扩展名
+func backupExtension(format string) (string, error) {
+	switch format {
+	case "json":
+		return ".json.gz", nil
+	case "archive":
+		return ".tar.gz", nil
+	case "database":
+		if ext := databaseBackupExtension(models.DB); ext != ".db" {
+			return ext + ".gz", nil
+		}
+		return ".db", nil
+	}
+	return "", fmt.Errorf("不支持的备份格式: %s", format)
+}
+
+func BackupFilePath(name string) string {
+	return filepath.Join(config.AppConfig.BackupDir, filepath.Base(name))
+}
+
+// 写出备份文件，先写临时文件，完成后再重命名，避免留下不完整的备份
+func writeBackupFile(name, format string, exportedAt time.Time) (int64, string, error) {
+	dir := config.AppConfig.BackupDir
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, "", fmt.Errorf("创建备份目录失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, name+".tmp*")
+	if err != nil {
+		return 0, "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	// 按配置加密和签名
+	out, err := sealBackup(tmp)
+	if err != nil {
+		return 0, "", err
+	}
+
+	switch format {
+	case "json":
+		gz := gzip.NewWriter(out)
+		if err := writeBackupJSON(models.DB, gz, exportedAt); err != nil {
+			return 0, "", err
+		}
+		if err := gz.Close(); err != nil {
+			return 0, "", err
+		}
+	case "archive":
+		if err := writeSiteArchive(models.DB, "tar.gz", out, exportedAt); err != nil {
+			return 0, "", err
+		}
+	case "database":
+		if models.DB.Dialector.Name() == "sqlite" {
+			if err := copySQLiteSnapshot(out, tmp.Name()+".db"); err != nil {
+				return 0, "", err
+			}
+			break
+		}
+		gz := gzip.NewWriter(out)
+		if err := writeSQLDump(models.DB, gz); err != nil {
+			return 0, "", err
+		}
+		if err := gz.Close(); err != nil {
+			return 0, "", err
+		}
+	}
+
+	if err := out.Close(); err != nil {
+		return 0, "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, "", err
+	}
+	final := BackupFilePath(name)
+	if err := os.Rename(tmp.Name(), final); err != nil {
+		return 0, "", err
+	}
+	return hashFile(final)
+}
+
+// 开始一次备份：加锁并创建运行记录
+func beginBackup(trigger string) (*models.Backup, error) {
+	format := config.AppConfig.BackupFormat
+	ext, err := backupExtension(format)
+	if err != nil {
+		return nil, err
+	}
+	ext += sealedExtension()
+
+	if !backupMutex.TryLock() {
+		return nil, errBackupRunning
+	}
+
+	backup := &models.Backup{
+		Format:    format,
+		Encrypted: strings.Contains(ext, encryptedSuffix),
+		Signed:    strings.HasSuffix(ext, signedBackupSuffix),
+		Trigger:   trigger,
+		Status:    models.BackupStatusRunning,
+		StartedAt: time.Now(),
+	}
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(backup).Error; err != nil {
+			return err
+		}
+		// 文件名带上记录ID，同一秒内多次备份也不会互相覆盖
+		backup.Filename = fmt.Sprintf("blog_backup_%s_%d%s", backup.StartedAt.Format("20060102_150405"), backup.ID, ext)
+		return tx.Model(backup).Update("filename", backup.Filename).Error
+	})
+	if err != nil {
+		backupMutex.Unlock()
+		return nil, err
+	}
+	return backup, nil
+}
+
+// 写出备份文件，记录结果并清理过期备份，完成后释放锁
+func finishBackup(backup *models.Backup) error {
+	defer backupMutex.Unlock()
+
+	size, checksum, err := writeBackupFile(backup.Filename, backup.Format, backup.StartedAt)
+	finishedAt := time.Now()
+	backup.FinishedAt = &finishedAt
+	if err != nil {
+		backup.Status = models.BackupStatusFailed
+		backup.Error = err.Error()
+	} else {
+		backup.Status = models.BackupStatusSuccess
+		backup.Size = size
+		backup.Checksum = checksum
+	}
+
+	updates := map[string]interface{}{
+		"status":      backup.Status,
+		"error":       backup.Error,
+		"size":        backup.Size,
+		"checksum":    backup.Checksum,
+		"finished_at": backup.FinishedAt,
+	}
+	if dbErr := models.DB.Model(backup).Updates(updates).Error; dbErr != nil && err == nil {
+		err = dbErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if n, err := applyBackupRetention(); err != nil {
+		log.Printf("清理过期备份失败: %v", err)
+	} else if n > 0 {
+		log.Printf("已删除 %d 个过期备份", n)
+	}
+	return nil
+}
+
+// 执行一次备份
+func RunBackup(trigger string) (*models.Backup, error) {
+	backup, err := beginBackup(trigger)
+	if err != nil {
+		return nil, err
+	}
+	return backup, finishBackup(backup)
+}
+
+// 删除备份文件和记录
+func removeBackup(backup models.Backup) error {
+	if err := os.Remove(BackupFilePath(backup.Filename)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return models.DB.Delete(&backup).Error
+}
+
+// 失败备份的最长保留时间，不受 BACKUP_RETENTION_DAYS=0 (不限制) 影响
+const failedBackupRetention = 7 * 24 * time.Hour
+
+// 按保留数量和天数删除旧备份，最新的成功备份始终保留
+// 失败的备份单独计数，同样最多保留 BACKUP_RETENTION_COUNT 个，并且最多保留7天
+func applyBackupRetention() (int, error) {
+	var backups []models.Backup
+	if err := models.DB.Where("status <> ?", models.BackupStatusRunning).
+		Order("started_at desc").Find(&backups).Error; err != nil {
+		return 0, err
+	}
+
+	keepCount := int(config.AppConfig.BackupRetentionCount)
+	var cutoff time.Time
+	if days := config.AppConfig.BackupRetentionDays; days > 0 {
+		cutoff = time.Now().AddDate(0, 0, -int(days))
+	}
+	tooOld := func(b models.Backup) bool {
+		return !cutoff.IsZero() && b.StartedAt.Before(cutoff)
+	}
+
+	removed := 0
+	kept, failed := 0, 0
+	for _, backup := range backups {
+		expired := tooOld(backup)
+		switch backup.Status {
+		case models.BackupStatusSuccess:
+			kept++
+			if kept == 1 {
+				continue
+			}
+			expired = expired || (keepCount > 0 && kept > keepCount)
+		case models.BackupStatusFailed:
+			failed++
+			expired = expired || time.Since(backup.StartedAt) > failedBackupRetention ||
+				(keepCount > 0 && failed > keepCount)
+		}
+		if !expired {
+			continue
+		}
+		if err := removeBackup(backup); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// 启动自动备份，spec 为 cron 表达式，为空时不启用
+func StartBackupScheduler(spec string) error {
+	// 服务重启前未完成的备份标记为失败
+	models.DB.Model(&models.Backup{}).Where("status = ?", models.BackupStatusRunning).
+		Updates(map[string]interface{}{"status": models.BackupStatusFailed, "error": "服务重启，备份中断"})
+
+	// 导出和手动备份也会用到加密配置，不启用自动备份时同样检查
+	if err := CheckBackupProtection(); err != nil {
+		return err
+	}
+
+	if spec == "" {
+		return nil
+	}
+	if _, err := backupExtension(config.AppConfig.BackupFormat); err != nil {
+		return err
+	}
+
+	scheduler := cron.New()
+	backupScheduler = scheduler
+	_, err := scheduler.AddFunc(spec, func() {
+		backup, err := RunBackup(models.BackupTriggerScheduled)
+		if err != nil {
+			log.Printf("自动备份失败: %v", err)
+			return
+		}
+		log.Printf("自动备份完成: %s (%d 字节)", backup.Filename, backup.Size)
+	})
+	if err != nil {
+		return fmt.Errorf("无效的备份计划 %q: %w", spec, err)
+	}
+	scheduler.Start()
+	log.Printf("已启用自动备份: %s", spec)
+	return nil
+}
+
+// 停止自动备份并等待正在进行的备份完成，之后不再开始新的备份，服务器退出前调用
+func StopBackups() {
+	if backupScheduler != nil {
+		<-backupScheduler.Stop().Done()
+	}
+	backupMutex.Lock()
+}
+
+// 获取备份列表 (仅管理员可用)
+func GetBackups(c *gin.Context) {
+	// 验证管理员权限
+	if !isAdmin(c) {
+		return
+	}
+
+	backups := []models.Backup{}
+	if err := models.DB.Order("started_at desc").Find(&backups).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取备份列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"backups":  backups,
+		"schedule": config.AppConfig.BackupSchedule,
+		"format":   config.AppConfig.BackupFormat,
+		"retention": gin.H{
+			"count": config.AppConfig.BackupRetentionCount,
+			"days":  config.AppConfig.BackupRetentionDays,
+		},
+	})
+}
+
+// 立即执行一次备份 (仅管理员可用)，备份在后台进行，通过列表查看结果
+func CreateBackup(c *gin.Context) {
+	// 验证管理员权限
+	if !isAdmin(c) {
+		return
+	}
+
+	backup, err := beginBackup(models.BackupTriggerManual)
+	if errors.Is(err, errBackupRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建备份失败: " + err.Error()})
+		return
+	}
+
+	started := *backup
+	go func() {
+		if err := finishBackup(backup); err != nil {
+			log.Printf("手动备份失败: %v", err)
+		}
+	}()
+
+	c.JSON(http.StatusAccepted, started)
+}
+
+// 下载备份文件 (仅管理员可用)
+func DownloadBackup(c *gin.Context) {
+	// 验证管理员权限
+	if !isAdmin(c) {
+		return
+	}
+
+	var backup models.Backup
+	if err := models.DB.First(&backup, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "备份不存在"})
+		return
+	}
+
+	if backup.Status != models.BackupStatusSuccess {
+		c.JSON(http.StatusConflict, gin.H{"error": "备份未成功完成，无法下载"})
+		return
+	}
+
+	path := BackupFilePath(backup.Filename)
+	if _, err := os.Stat(path); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "备份文件不存在"})
+		return
+	}
+
+	c.Header("X-Backup-SHA256", backup.Checksum)
+	c.FileAttachment(path, backup.Filename)
+}
+
+// 删除备份 (仅管理员可用)
+func DeleteBackup(c *gin.Context) {
+	// 验证管理员权限
+	if !isAdmin(c) {
+		return
+	}
+
+	var backup models.Backup
+	if err := models.DB.First(&backup, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "备份不存在"})
+		return
+	}
+
+	if backup.Status == models.BackupStatusRunning {
+		c.JSON(http.StatusConflict, gin.H{"error": "备份正在进行，无法删除"})
+		return
+	}
+
+	if err := removeBackup(backup); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除备份失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "备份删除成功"})
+}
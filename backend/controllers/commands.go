@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"blog-backend/models"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 命令行管理工具使用的操作，与对应的HTTP接口和启动时的初始化共用实现
+
+// 创建管理员账户，用户名已存在时返回错误
+func CreateAdmin(username, email, password string) (*models.User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" || password == "" {
+		return nil, errors.New("用户名和密码不能为空")
+	}
+
+	var count int64
+	models.DB.Model(&models.User{}).Where("username = ?", username).Count(&count)
+	if count > 0 {
+		return nil, fmt.Errorf("用户名 %s 已存在", username)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.New("密码加密失败")
+	}
+
+	admin := &models.User{
+		Username: username,
+		Password: string(hashedPassword),
+		Email:    email,
+		UserType: models.UserTypeAdmin,
+		Status:   models.UserStatusActive,
+	}
+	if err := models.DB.Create(admin).Error; err != nil {
+		return nil, fmt.Errorf("创建管理员失败: %w", err)
+	}
+	return admin, nil
+}
+
+// 加密并保存用户的新密码
+func setUserPassword(user *models.User, password string) error {
+	if password == "" {
+		return errors.New("密码不能为空")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("密码加密失败")
+	}
+	if err := models.DB.Model(user).Update("password", string(hashedPassword)).Error; err != nil {
+		return fmt.Errorf("更新密码失败: %w", err)
+	}
+	return nil
+}
+
+// 重置用户密码
+func ResetUserPassword(username, password string) error {
+	var user models.User
+	if err := models.DB.Where("username = ?", username).First(&user).Error; err != nil {
+		return fmt.Errorf("用户 %s 不存在", username)
+	}
+	return setUserPassword(&user, password)
+}
+
+// 导出数据到本地文件，格式由 format 指定，为空时按扩展名判断：
+// .json、.ndjson/.jsonl 为数据导出，.tar.gz/.tgz、.zip 为包含上传文件的站点归档
+// 按配置加密和签名，先写临时文件再重命名
+func ExportToFile(path, format string) error {
+	if format == "" {
+		name := strings.ToLower(path)
+		switch {
+		case strings.HasSuffix(name, ".ndjson"), strings.HasSuffix(name, ".jsonl"):
+			format = "ndjson"
+		case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+			format = "tar.gz"
+		case strings.HasSuffix(name, ".zip"):
+			format = "zip"
+		default:
+			format = "json"
+		}
+	}
+	if format != "json" && format != "ndjson" && format != "tar.gz" && format != "zip" {
+		return fmt.Errorf("不支持的导出格式: %s", format)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	out, err := sealBackup(tmp)
+	if err != nil {
+		return err
+	}
+
+	exportedAt := time.Now()
+	switch format {
+	case "json":
+		err = writeBackupJSON(models.DB, out, exportedAt)
+	case "ndjson":
+		err = writeBackupNDJSON(models.DB, out, exportedAt)
+	default:
+		err = writeSiteArchive(models.DB, format, out, exportedAt)
+	}
+	if err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// 导入本地文件，支持的格式、签名和加密与导入接口相同
+func ImportFile(path string, options ImportOptions, passphrase string) (*ImportReport, map[string]int, gin.H, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	source, err := openImportSource(f, stat.Size(), filepath.Base(path), passphrase)
+	if err != nil {
+		var schemaErr *backupSchemaError
+		if errors.As(err, &schemaErr) {
+			err = fmt.Errorf("%s\n  %s", schemaErr.Error(), strings.Join(schemaErr.details, "\n  "))
+		}
+		return nil, nil, nil, err
+	}
+	defer source.cleanup()
+
+	report, results, err := importBackupData(source.data, options, source.afterCommit(options.ClearExisting))
+	return report, results, source.info, err
+}
+
+// 重建派生数据：为缺少slug的文章生成slug，按点赞记录重新计算点赞数，重新计算文章热度
+func Reindex() (map[string]int64, error) {
+	results := make(map[string]int64)
+
+	var missing int64
+	models.DB.Model(&models.Post{}).Where("slug = ? OR slug IS NULL", "").Count(&missing)
+	BackfillPostSlugs()
+	results["slugs"] = missing
+
+	fixed, err := recountPostLikes(models.DB)
+	if err != nil {
+		return results, fmt.Errorf("重新计算点赞数失败: %w", err)
+	}
+	results["likes"] = fixed
+
+	trending, err := RecomputeTrendingScores()
+	if err != nil {
+		return results, fmt.Errorf("计算文章热度失败: %w", err)
+	}
+	results["trending"] = int64(trending)
+	return results, nil
+}
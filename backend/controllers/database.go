@@ -89,6 +89,15 @@ func CleanDatabase(c *gin.Context) {
 			return
 		}
 
+		// 清理文章统计和系列成员
+		for _, table := range []string{"post_daily_stats", "post_referrer_stats", "series_posts"} {
+			if err := tx.Exec("DELETE FROM " + table).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "清理 " + table + " 失败: " + err.Error()})
+				return
+			}
+		}
+
 		if err := tx.Delete(&models.Post{}, "1 = 1").Error; err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "清理文章数据失败: " + err.Error()})
@@ -135,6 +144,7 @@ func CleanDatabase(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "提交事务失败: " + err.Error()})
 		return
 	}
+	invalidateRelatedPosts()
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "数据清理完成",
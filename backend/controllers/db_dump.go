@@ -0,0 +1,284 @@
+package controllers
+
+import (
+	"blog-backend/migrations"
+	"blog-backend/models"
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 每条 INSERT 语句包含的最大行数
+const sqlDumpBatchRows = 100
+
+// 有外键依赖的表按依赖顺序导出，其余表按名称排序排在后面
+var sqlDumpTableOrder = []string{"users", "tags", "posts", "post_tags", "post_likes"}
+
+// 数据库备份文件的扩展名：SQLite 为数据库快照，其他数据库为SQL转储
+func databaseBackupExtension(db *gorm.DB) string {
+	if db.Dialector.Name() == "sqlite" {
+		return ".db"
+	}
+	return ".sql"
+}
+
+// 用 VACUUM INTO 生成 SQLite 数据库的一致性快照，dst 不能已存在
+// 快照在一个读事务中完成，不受同时进行的写入影响
+func snapshotSQLite(db *gorm.DB, dst string) error {
+	if err := db.Exec("VACUUM INTO ?", dst).Error; err != nil {
+		return fmt.Errorf("生成数据库快照失败: %w", err)
+	}
+	return nil
+}
+
+// 生成 SQLite 快照并写入 out，tmpPath 为快照使用的临时文件
+func copySQLiteSnapshot(out io.Writer, tmpPath string) error {
+	if err := snapshotSQLite(models.DB, tmpPath); err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	f, err := os.Open(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(out, f)
+	return err
+}
+
+// 按导出顺序排列数据表
+func sqlDumpTables(db *gorm.DB) ([]string, error) {
+	all, err := db.Migrator().GetTables()
+	if err != nil {
+		return nil, err
+	}
+	// 跳过 SQLite 内部表和迁移记录表：恢复前启动程序时已经写入了迁移记录，再导入会主键冲突
+	tables := all[:0]
+	for _, table := range all {
+		if !strings.HasPrefix(table, "sqlite_") && table != migrations.TableName {
+			tables = append(tables, table)
+		}
+	}
+
+	rank := make(map[string]int)
+	for i, table := range sqlDumpTableOrder {
+		rank[table] = i + 1
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		ri, rj := rank[tables[i]], rank[tables[j]]
+		if ri == 0 {
+			ri = len(sqlDumpTableOrder) + 1
+		}
+		if rj == 0 {
+			rj = len(sqlDumpTableOrder) + 1
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		return tables[i] < tables[j]
+	})
+	return tables, nil
+}
+
+// 按数据库方言生成SQL字面量
+type sqlDumpDialect struct {
+	name string
+}
+
+func (d sqlDumpDialect) quoteIdent(name string) string {
+	if d.name == "mysql" {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (d sqlDumpDialect) quoteString(s string) string {
+	if d.name == "mysql" {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		s = strings.ReplaceAll(s, "\x00", `\0`)
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func (d sqlDumpDialect) literal(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return d.quoteString(string(value))
+	case string:
+		return d.quoteString(value)
+	case bool:
+		if d.name == "postgres" {
+			if value {
+				return "TRUE"
+			}
+			return "FALSE"
+		}
+		if value {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		if d.name == "mysql" {
+			// MySQL 的 DATETIME 不带时区，连接使用本地时区
+			return d.quoteString(value.Local().Format("2006-01-02 15:04:05.999999"))
+		}
+		return d.quoteString(value.Format("2006-01-02 15:04:05.999999-07:00"))
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// 导出一张表的所有数据为 INSERT 语句，返回行数
+func dumpTable(tx *gorm.DB, w *bufio.Writer, d sqlDumpDialect, table string) (int, error) {
+	rows, err := tx.Table(table).Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = d.quoteIdent(column)
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES\n", d.quoteIdent(table), strings.Join(quoted, ", "))
+
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	count := 0
+	literals := make([]string, len(columns))
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return count, err
+		}
+		for i, v := range values {
+			literals[i] = d.literal(v)
+		}
+
+		if count%sqlDumpBatchRows == 0 {
+			if count > 0 {
+				w.WriteString(";\n")
+			}
+			w.WriteString(insert)
+		} else {
+			w.WriteString(",\n")
+		}
+		w.WriteString("  (" + strings.Join(literals, ", ") + ")")
+		count++
+	}
+	if count > 0 {
+		w.WriteString(";\n")
+	}
+	return count, rows.Err()
+}
+
+// 开始读取快照的事务，保证分多次读取的各表数据一致，调用方负责回滚
+// MySQL 和 PostgreSQL 使用可重复读的只读事务，SQLite 的事务在结束前一直读取同一快照
+func beginSnapshot(db *gorm.DB) *gorm.DB {
+	if db.Dialector.Name() == "sqlite" {
+		return db.Begin()
+	}
+	return db.Begin(&sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+}
+
+// 生成只包含数据的SQL转储，表结构由程序启动时的迁移创建
+// 在快照事务中读取，保证各表数据一致
+func writeSQLDump(db *gorm.DB, out io.Writer) error {
+	d := sqlDumpDialect{name: db.Dialector.Name()}
+
+	tx := beginSnapshot(db)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	defer tx.Rollback()
+
+	tables, err := sqlDumpTables(tx)
+	if err != nil {
+		return fmt.Errorf("获取数据表失败: %w", err)
+	}
+
+	w := bufio.NewWriterSize(out, 64*1024)
+	fmt.Fprintf(w, "-- 博客数据转储 (%s)\n", d.name)
+	fmt.Fprintf(w, "-- 导出时间: %s\n", time.Now().Format(time.RFC3339))
+	w.WriteString("-- 只包含数据，不包含迁移记录 (schema_migrations)\n")
+	w.WriteString("-- 恢复步骤：在空库上用相同版本的博客程序启动一次创建表结构，停止程序，\n")
+	w.WriteString("-- 执行 DELETE FROM users; 删除启动时创建的默认管理员，再导入本文件\n\n")
+	if d.name == "mysql" {
+		w.WriteString("SET FOREIGN_KEY_CHECKS = 0;\n")
+	}
+	w.WriteString("BEGIN;\n")
+
+	for _, table := range tables {
+		fmt.Fprintf(w, "\n-- %s\n", table)
+		if _, err := dumpTable(tx, w, d, table); err != nil {
+			return fmt.Errorf("导出表 %s 失败: %w", table, err)
+		}
+	}
+
+	// PostgreSQL 的自增序列需要同步到最大ID
+	if d.name == "postgres" {
+		w.WriteString("\n")
+		for _, table := range tables {
+			if !tx.Migrator().HasColumn(table, "id") {
+				continue
+			}
+			fmt.Fprintf(w, "SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE((SELECT MAX(id) FROM %s), 0) + 1, false);\n",
+				table, d.quoteIdent(table))
+		}
+	}
+
+	w.WriteString("\nCOMMIT;\n")
+	if d.name == "mysql" {
+		w.WriteString("SET FOREIGN_KEY_CHECKS = 1;\n")
+	}
+	return w.Flush()
+}
+
+// 写出数据库备份到文件：SQLite 为快照，其他数据库为SQL转储
+func writeDatabaseBackupFile(db *gorm.DB, dst string) error {
+	if db.Dialector.Name() == "sqlite" {
+		return snapshotSQLite(db, dst)
+	}
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if err := writeSQLDump(db, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// 生成数据库备份临时文件，调用方负责删除
+func createDatabaseBackup() (string, error) {
+	dir, err := os.MkdirTemp("", "blog_db_backup_*")
+	if err != nil {
+		return "", err
+	}
+	dst := filepath.Join(dir, "backup"+databaseBackupExtension(models.DB))
+	if err := writeDatabaseBackupFile(models.DB, dst); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dst, nil
+}
@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"blog-backend/models"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"gorm.io/gorm"
+)
+
+// 导出时每批读取的记录数
+const exportBatchSize = 500
+
+// 流式导出的数据段，名称与 BackupData 的JSON字段一致
+var exportSections = []struct {
+	name   string
+	stream func(db *gorm.DB, emit func(interface{}) error) error
+}{
+	{"posts", func(db *gorm.DB, emit func(interface{}) error) error {
+		return streamRecords[models.Post](db.Preload("Tags"), emit)
+	}},
+	// 标签不再预加载 Posts，文章与标签的关联已经包含在 posts[].tags 中
+	{"tags", func(db *gorm.DB, emit func(interface{}) error) error {
+		return streamRecords[models.Tag](db, emit)
+	}},
+	{"users", func(db *gorm.DB, emit func(interface{}) error) error {
+		return streamRecords[models.User](db, emit)
+	}},
+	{"post_likes", func(db *gorm.DB, emit func(interface{}) error) error {
+		return streamRecords[models.PostLike](db, emit)
+	}},
+	{"series", func(db *gorm.DB, emit func(interface{}) error) error {
+		return streamRecords[models.Series](db, emit)
+	}},
+	// 系列成员是复合主键，不能用 FindInBatches，逐行读取
+	{"series_posts", func(db *gorm.DB, emit func(interface{}) error) error {
+		rows, err := db.Model(&models.SeriesPost{}).Order("series_id, position").Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var member models.SeriesPost
+			if err := db.ScanRows(rows, &member); err != nil {
+				return err
+			}
+			if err := emit(&member); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	}},
+}
+
+// 分批读取表中所有记录
+func streamRecords[T any](db *gorm.DB, emit func(interface{}) error) error {
+	var batch []T
+	return db.FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if err := emit(&batch[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// 统计导出记录总数，便于客户端显示进度
+func countExportRecords(db *gorm.DB) int64 {
+	var total int64
+	for _, model := range []interface{}{&models.Post{}, &models.Tag{}, &models.User{}, &models.PostLike{},
+		&models.Series{}, &models.SeriesPost{}} {
+		var count int64
+		db.Model(model).Count(&count)
+		total += count
+	}
+	return total
+}
+
+// 备份写入器，每批数据后刷新到客户端
+type backupWriter struct {
+	w       *bufio.Writer
+	flusher http.Flusher
+}
+
+func newBackupWriter(w io.Writer) *backupWriter {
+	bw := &backupWriter{w: bufio.NewWriterSize(w, 64*1024)}
+	if f, ok := w.(http.Flusher); ok {
+		bw.flusher = f
+	}
+	return bw
+}
+
+func (bw *backupWriter) flush() error {
+	if err := bw.w.Flush(); err != nil {
+		return err
+	}
+	if bw.flusher != nil {
+		bw.flusher.Flush()
+	}
+	return nil
+}
+
+// 以 BackupData 的JSON结构流式写出全部数据
+// 所有数据段在同一个快照事务中读取，导出期间的写入不会造成文章、点赞等数据互相不一致
+func writeBackupJSON(db *gorm.DB, out io.Writer, exportedAt time.Time) error {
+	tx := beginSnapshot(db)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	defer tx.Rollback()
+
+	bw := newBackupWriter(out)
+	header, _ := json.Marshal(exportedAt)
+	fmt.Fprintf(bw.w, "{\n  \"version\": %q,\n  \"exported_at\": %s", backupVersion, header)
+
+	for _, section := range exportSections {
+		fmt.Fprintf(bw.w, ",\n  %q: [", section.name)
+		count := 0
+		err := section.stream(tx, func(record interface{}) error {
+			data, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				bw.w.WriteByte(',')
+			}
+			bw.w.WriteString("\n    ")
+			bw.w.Write(data)
+			count++
+			if count%exportBatchSize == 0 {
+				return bw.flush()
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("导出%s失败: %w", section.name, err)
+		}
+		if count > 0 {
+			bw.w.WriteString("\n  ")
+		}
+		bw.w.WriteByte(']')
+		if err := bw.flush(); err != nil {
+			return err
+		}
+	}
+
+	bw.w.WriteString("\n}\n")
+	return bw.flush()
+}
+
+// NDJSON 备份中的一行
+type backupRecord struct {
+	Type       string          `json:"type"`
+	Version    string          `json:"version,omitempty"`
+	ExportedAt *time.Time      `json:"exported_at,omitempty"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+// 以 NDJSON 流式写出全部数据：第一行为 meta，之后每行一条记录
+// 与 writeBackupJSON 相同，在同一个快照事务中读取
+func writeBackupNDJSON(db *gorm.DB, out io.Writer, exportedAt time.Time) error {
+	tx := beginSnapshot(db)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	defer tx.Rollback()
+
+	bw := newBackupWriter(out)
+	enc := json.NewEncoder(bw.w)
+
+	if err := enc.Encode(backupRecord{Type: "meta", Version: backupVersion, ExportedAt: &exportedAt}); err != nil {
+		return err
+	}
+
+	for _, section := range exportSections {
+		count := 0
+		err := section.stream(tx, func(record interface{}) error {
+			data, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			if err := enc.Encode(backupRecord{Type: section.name, Data: data}); err != nil {
+				return err
+			}
+			count++
+			if count%exportBatchSize == 0 {
+				return bw.flush()
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("导出%s失败: %w", section.name, err)
+		}
+	}
+
+	return bw.flush()
+}
+
+// 读取 NDJSON 备份，组装成与JSON备份相同结构的文档，由 decodeBackupDocument 校验和升级
+func readBackupNDJSON(r io.Reader) (map[string]interface{}, error) {
+	doc := map[string]interface{}{}
+	for _, section := range exportSections {
+		doc[section.name] = []interface{}{}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record backupRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("第%d行: %w", line, err)
+		}
+
+		if record.Type == "meta" {
+			doc["version"] = record.Version
+			if record.ExportedAt != nil {
+				doc["exported_at"] = record.ExportedAt.Format(time.RFC3339Nano)
+			}
+			continue
+		}
+
+		records, ok := doc[record.Type].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("第%d行: 未知的记录类型: %s", line, record.Type)
+		}
+		value, err := jsonschema.UnmarshalJSON(bytes.NewReader(record.Data))
+		if err != nil {
+			return nil, fmt.Errorf("第%d行: %w", line, err)
+		}
+		doc[record.Type] = append(records, value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
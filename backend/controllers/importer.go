@@ -0,0 +1,451 @@
+package controllers
+
+import (
+	"blog-backend/models"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 导入选项
+type ImportOptions struct {
+	ClearExisting bool // 是否清除现有数据
+	MergeMode     bool // 是否合并模式：已存在的用户名/标签名跳过或复用
+	DryRun        bool // 只生成报告，不提交
+	PreserveIDs   bool // 保留原始ID，只能导入到空数据库
+}
+
+// 从表单读取导入选项，merge_mode 默认开启
+func parseImportOptions(c *gin.Context) ImportOptions {
+	parse := func(key, def string) bool {
+		v, err := strconv.ParseBool(c.DefaultPostForm(key, c.DefaultQuery(key, def)))
+		if err != nil {
+			v, _ = strconv.ParseBool(def)
+		}
+		return v
+	}
+	return ImportOptions{
+		ClearExisting: parse("clear_existing", "false"),
+		MergeMode:     parse("merge_mode", "true"),
+		DryRun:        parse("dry_run", "false"),
+		PreserveIDs:   parse("preserve_ids", "false"),
+	}
+}
+
+// 单类数据的导入报告
+type EntityReport struct {
+	Total     int      `json:"total"`
+	Created   int      `json:"created"`
+	Updated   int      `json:"updated,omitempty"`
+	Skipped   int      `json:"skipped"`
+	Conflicts []string `json:"conflicts"`
+}
+
+func newEntityReport(total int) *EntityReport {
+	return &EntityReport{Total: total, Conflicts: []string{}}
+}
+
+func (r *EntityReport) skip(format string, args ...interface{}) {
+	r.Skipped++
+	r.Conflicts = append(r.Conflicts, fmt.Sprintf(format, args...))
+}
+
+// 导入报告
+type ImportReport struct {
+	DryRun      bool          `json:"dry_run"`
+	Valid       bool          `json:"valid"`  // 没有阻断性问题，可以正式导入
+	Errors      []string      `json:"errors"` // 会导致正式导入失败的问题
+	Users       *EntityReport `json:"users"`
+	Tags        *EntityReport `json:"tags"`
+	Posts       *EntityReport `json:"posts"`
+	PostLikes   *EntityReport `json:"post_likes"`
+	Series      *EntityReport `json:"series"`
+	SeriesPosts *EntityReport `json:"series_posts"`
+	Files       *EntityReport `json:"files,omitempty"` // 站点归档中的上传文件
+}
+
+// 兼容旧接口的各类新建数量
+func (r *ImportReport) results() map[string]int {
+	return map[string]int{
+		"users":        r.Users.Created,
+		"tags":         r.Tags.Created,
+		"posts":        r.Posts.Created,
+		"post_likes":   r.PostLikes.Created,
+		"series":       r.Series.Created,
+		"series_posts": r.SeriesPosts.Created,
+	}
+}
+
+// 导入错误，正式导入时中断事务
+type importError struct {
+	msg string
+}
+
+func (e *importError) Error() string { return e.msg }
+
+// 在事务中执行导入并生成报告
+// 试运行时阻断性问题只记录到报告中，继续检查其余数据；调用方负责回滚
+func runImport(tx *gorm.DB, data *BackupData, opts ImportOptions) (*ImportReport, error) {
+	report := &ImportReport{
+		DryRun:      opts.DryRun,
+		Errors:      []string{},
+		Users:       newEntityReport(len(data.Users)),
+		Tags:        newEntityReport(len(data.Tags)),
+		Posts:       newEntityReport(len(data.Posts)),
+		PostLikes:   newEntityReport(len(data.PostLikes)),
+		Series:      newEntityReport(len(data.Series)),
+		SeriesPosts: newEntityReport(len(data.SeriesPosts)),
+	}
+
+	// 阻断性问题：正式导入直接失败，试运行记录后继续
+	fail := func(format string, args ...interface{}) error {
+		msg := fmt.Sprintf(format, args...)
+		report.Errors = append(report.Errors, msg)
+		if opts.DryRun {
+			return nil
+		}
+		return &importError{msg: msg}
+	}
+
+	// 如果选择清除现有数据
+	if opts.ClearExisting {
+		// 删除关联表数据
+		if err := tx.Exec("DELETE FROM post_tags").Error; err != nil {
+			return report, fmt.Errorf("清除文章标签关联失败: %w", err)
+		}
+
+		// 删除主表数据
+		tables := []interface{}{&models.PostDailyStat{}, &models.PostReferrerStat{}, &models.SeriesPost{},
+			&models.Series{}, &models.PostLike{}, &models.Post{}, &models.Tag{}}
+		for _, table := range tables {
+			if err := tx.Delete(table, "1 = 1").Error; err != nil {
+				return report, fmt.Errorf("清除现有数据失败: %w", err)
+			}
+		}
+
+		// 导出文件不包含密码，保留现有管理员账户，否则导入后没有账户可以登录
+		if err := tx.Where("user_type <> ?", models.UserTypeAdmin).Delete(&models.User{}).Error; err != nil {
+			return report, fmt.Errorf("清除现有数据失败: %w", err)
+		}
+	}
+
+	// 保留ID时要求文章、标签、点赞和系列表为空，用户只允许按用户名合并到相同ID
+	if opts.PreserveIDs {
+		for _, table := range []interface{}{&models.Post{}, &models.Tag{}, &models.PostLike{}, &models.Series{}} {
+			var count int64
+			if err := tx.Model(table).Count(&count).Error; err != nil {
+				return report, err
+			}
+			if count > 0 {
+				if err := fail("保留ID导入需要空数据库，请同时开启 clear_existing"); err != nil {
+					return report, err
+				}
+				break
+			}
+		}
+	}
+
+	// 导入用户
+	userMapping := make(map[uint]uint) // 旧ID -> 新ID
+	seenUsernames := make(map[string]uint)
+	for _, user := range data.Users {
+		oldID := user.ID
+		if _, ok := userMapping[oldID]; ok {
+			report.Users.skip("文件中用户ID重复: %d", oldID)
+			continue
+		}
+		if newID, ok := seenUsernames[user.Username]; ok {
+			userMapping[oldID] = newID
+			report.Users.skip("文件中用户名重复: %s", user.Username)
+			continue
+		}
+
+		// 检查用户名是否已存在，合并模式下点赞等数据归到现有用户
+		var existingUser models.User
+		if err := tx.Where("username = ?", user.Username).First(&existingUser).Error; err == nil {
+			if opts.PreserveIDs && existingUser.ID != oldID {
+				report.Users.skip("用户名已存在且ID不同: %s", user.Username)
+				if err := fail("保留ID冲突: 用户 %s 的ID为 %d，文件中为 %d", user.Username, existingUser.ID, oldID); err != nil {
+					return report, err
+				}
+				continue
+			}
+			// 清除现有数据时保留的管理员同样按用户名合并
+			if opts.MergeMode || opts.PreserveIDs || opts.ClearExisting {
+				userMapping[oldID] = existingUser.ID
+				seenUsernames[user.Username] = existingUser.ID
+				report.Users.skip("用户名已存在，跳过: %s", user.Username)
+				continue
+			}
+			report.Users.skip("用户名已存在: %s", user.Username)
+			if err := fail("用户名已存在: %s", user.Username); err != nil {
+				return report, err
+			}
+			continue
+		}
+
+		if opts.PreserveIDs {
+			var count int64
+			tx.Model(&models.User{}).Where("id = ?", oldID).Count(&count)
+			if count > 0 {
+				report.Users.skip("用户ID已被占用: %d (%s)", oldID, user.Username)
+				if err := fail("保留ID冲突: 用户ID %d 已被占用", oldID); err != nil {
+					return report, err
+				}
+				continue
+			}
+		} else {
+			user.ID = 0 // 重置ID，让数据库自动分配
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return report, fmt.Errorf("导入用户数据失败: %w", err)
+		}
+		userMapping[oldID] = user.ID
+		seenUsernames[user.Username] = user.ID
+		report.Users.Created++
+	}
+
+	// 导入标签
+	tagMapping := make(map[uint]uint) // 旧ID -> 新ID
+	seenTagNames := make(map[string]uint)
+	for _, tag := range data.Tags {
+		oldID := tag.ID
+		if newID, ok := seenTagNames[tag.Name]; ok {
+			tagMapping[oldID] = newID
+			report.Tags.skip("文件中标签名重复，已合并: %s", tag.Name)
+			continue
+		}
+
+		// 检查标签名是否已存在
+		var existingTag models.Tag
+		if err := tx.Where("name = ?", tag.Name).First(&existingTag).Error; err == nil {
+			if opts.MergeMode {
+				tagMapping[oldID] = existingTag.ID
+				seenTagNames[tag.Name] = existingTag.ID
+				report.Tags.skip("标签名已存在，复用现有标签: %s", tag.Name)
+				continue
+			}
+			report.Tags.skip("标签名已存在: %s", tag.Name)
+			if err := fail("标签名已存在: %s", tag.Name); err != nil {
+				return report, err
+			}
+			continue
+		}
+
+		if !opts.PreserveIDs {
+			tag.ID = 0
+		}
+		tag.Posts = nil // 清除关联，稍后重建
+		if err := tx.Create(&tag).Error; err != nil {
+			return report, fmt.Errorf("导入标签数据失败: %w", err)
+		}
+		tagMapping[oldID] = tag.ID
+		seenTagNames[tag.Name] = tag.ID
+		report.Tags.Created++
+	}
+
+	// 导入文章
+	postMapping := make(map[uint]uint) // 旧ID -> 新ID
+	for _, post := range data.Posts {
+		oldID := post.ID
+		if _, ok := postMapping[oldID]; ok {
+			report.Posts.skip("文件中文章ID重复: %d", oldID)
+			continue
+		}
+
+		// 处理标签关联
+		var newTags []models.Tag
+		for _, tag := range post.Tags {
+			newTagID, exists := tagMapping[tag.ID]
+			if !exists {
+				report.Posts.Conflicts = append(report.Posts.Conflicts,
+					fmt.Sprintf("文章「%s」引用的标签不存在: %s", post.Title, tag.Name))
+				continue
+			}
+			var newTag models.Tag
+			if err := tx.First(&newTag, newTagID).Error; err == nil {
+				newTags = append(newTags, newTag)
+			}
+		}
+
+		// 作者映射到新用户ID，找不到时置为匿名
+		if post.AuthorID != 0 {
+			post.AuthorID = userMapping[post.AuthorID]
+		}
+		if !opts.PreserveIDs {
+			post.ID = 0
+		}
+		post.Tags = newTags
+		post.Likes = 0 // 稍后根据导入的点赞重新计算
+
+		// 旧备份没有slug，或slug与现有文章重复时重新生成
+		oldSlug := post.Slug
+		if err := preparePostSlug(tx, &post); err != nil {
+			return report, fmt.Errorf("生成文章slug失败: %w", err)
+		}
+		if err := tx.Create(&post).Error; err != nil {
+			return report, fmt.Errorf("导入文章数据失败: %w", err)
+		}
+		if err := assignPostSlug(tx, &post); err != nil {
+			return report, fmt.Errorf("生成文章slug失败: %w", err)
+		}
+		if oldSlug != "" && oldSlug != post.Slug {
+			report.Posts.Conflicts = append(report.Posts.Conflicts,
+				fmt.Sprintf("文章「%s」的slug已被使用，改为: %s", post.Title, post.Slug))
+		}
+		postMapping[oldID] = post.ID
+		report.Posts.Created++
+	}
+
+	// 导入点赞数据，跳过引用不存在的文章或用户以及重复的点赞
+	type likeKey struct{ userID, postID uint }
+	seenLikes := make(map[likeKey]bool)
+	for _, postLike := range data.PostLikes {
+		newPostID, ok := postMapping[postLike.PostID]
+		if !ok {
+			report.PostLikes.skip("点赞引用的文章不存在: post_id=%d", postLike.PostID)
+			continue
+		}
+		newUserID, ok := userMapping[postLike.UserID]
+		if !ok {
+			report.PostLikes.skip("点赞引用的用户不存在: user_id=%d", postLike.UserID)
+			continue
+		}
+		key := likeKey{newUserID, newPostID}
+		if seenLikes[key] {
+			report.PostLikes.skip("重复的点赞: user_id=%d post_id=%d", postLike.UserID, postLike.PostID)
+			continue
+		}
+		seenLikes[key] = true
+
+		like := models.PostLike{UserID: newUserID, PostID: newPostID}
+		if opts.PreserveIDs {
+			like.ID = postLike.ID
+		}
+		if err := tx.Create(&like).Error; err != nil {
+			return report, fmt.Errorf("导入点赞数据失败: %w", err)
+		}
+		report.PostLikes.Created++
+	}
+
+	// 导入系列，slug已存在时合并模式复用现有系列
+	seriesMapping := make(map[uint]uint) // 旧ID -> 新ID
+	for _, series := range data.Series {
+		oldID := series.ID
+		if _, ok := seriesMapping[oldID]; ok {
+			report.Series.skip("文件中系列ID重复: %d", oldID)
+			continue
+		}
+
+		var existingSeries models.Series
+		if err := tx.Where("slug = ?", series.Slug).First(&existingSeries).Error; err == nil {
+			if opts.MergeMode {
+				seriesMapping[oldID] = existingSeries.ID
+				report.Series.skip("系列slug已存在，复用现有系列: %s", series.Slug)
+				continue
+			}
+			report.Series.skip("系列slug已存在: %s", series.Slug)
+			if err := fail("系列slug已存在: %s", series.Slug); err != nil {
+				return report, err
+			}
+			continue
+		}
+
+		if !opts.PreserveIDs {
+			series.ID = 0
+		}
+		if err := tx.Create(&series).Error; err != nil {
+			return report, fmt.Errorf("导入系列数据失败: %w", err)
+		}
+		seriesMapping[oldID] = series.ID
+		report.Series.Created++
+	}
+
+	// 导入系列成员，按文件中的顺序重新编号；复用现有系列时接在已有文章之后
+	members := append([]models.SeriesPost(nil), data.SeriesPosts...)
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].SeriesID != members[j].SeriesID {
+			return members[i].SeriesID < members[j].SeriesID
+		}
+		return members[i].Position < members[j].Position
+	})
+	lastPositions := make(map[uint]int) // 新系列ID -> 已使用的最大位置
+	seenMemberPosts := make(map[uint]bool)
+	for _, member := range members {
+		newSeriesID, ok := seriesMapping[member.SeriesID]
+		if !ok {
+			report.SeriesPosts.skip("系列成员引用的系列不存在: series_id=%d", member.SeriesID)
+			continue
+		}
+		newPostID, ok := postMapping[member.PostID]
+		if !ok {
+			report.SeriesPosts.skip("系列成员引用的文章不存在: post_id=%d", member.PostID)
+			continue
+		}
+		if seenMemberPosts[newPostID] {
+			report.SeriesPosts.skip("文章已属于其他系列: post_id=%d", member.PostID)
+			continue
+		}
+		seenMemberPosts[newPostID] = true
+
+		position, ok := lastPositions[newSeriesID]
+		if !ok {
+			if err := tx.Model(&models.SeriesPost{}).Where("series_id = ?", newSeriesID).
+				Select("COALESCE(MAX(position), 0)").Scan(&position).Error; err != nil {
+				return report, fmt.Errorf("导入系列成员失败: %w", err)
+			}
+		}
+		position++
+		lastPositions[newSeriesID] = position
+
+		if err := tx.Create(&models.SeriesPost{SeriesID: newSeriesID, PostID: newPostID, Position: position}).Error; err != nil {
+			return report, fmt.Errorf("导入系列成员失败: %w", err)
+		}
+		report.SeriesPosts.Created++
+	}
+
+	// 根据导入的点赞记录重新计算文章点赞数
+	if len(postMapping) > 0 {
+		newPostIDs := make([]uint, 0, len(postMapping))
+		for _, id := range postMapping {
+			newPostIDs = append(newPostIDs, id)
+		}
+		for start := 0; start < len(newPostIDs); start += exportBatchSize {
+			end := start + exportBatchSize
+			if end > len(newPostIDs) {
+				end = len(newPostIDs)
+			}
+			if err := tx.Exec(`UPDATE posts SET likes = (
+				SELECT COUNT(*) FROM post_likes WHERE post_likes.post_id = posts.id
+			) WHERE id IN ?`, newPostIDs[start:end]).Error; err != nil {
+				return report, fmt.Errorf("重新计算点赞数失败: %w", err)
+			}
+		}
+	}
+
+	if opts.PreserveIDs {
+		if err := models.ResetSequences(tx, "users", "tags", "posts", "post_likes", "series"); err != nil {
+			return report, err
+		}
+	}
+
+	// 导入的用户没有密码，清除现有数据后至少要有一个可以登录的管理员
+	if opts.ClearExisting {
+		var admins int64
+		if err := tx.Model(&models.User{}).Where("user_type = ? AND password <> ?", models.UserTypeAdmin, "").
+			Count(&admins).Error; err != nil {
+			return report, err
+		}
+		if admins == 0 {
+			if err := fail("清除现有数据后没有可以登录的管理员账户，请先创建管理员"); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	report.Valid = len(report.Errors) == 0
+	return report, nil
+}
@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"blog-backend/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 报告中每类问题最多列出的条目数
+const integritySampleLimit = 100
+
+// 点赞数与点赞记录不一致的文章
+type LikeCountMismatch struct {
+	PostID uint  `json:"post_id"`
+	Stored int64 `json:"stored"`
+	Actual int64 `json:"actual"`
+}
+
+// 同一用户对同一文章的重复点赞
+type DuplicateLike struct {
+	UserID uint  `json:"user_id"`
+	PostID uint  `json:"post_id"`
+	Count  int64 `json:"count"`
+}
+
+// 关联表中指向不存在记录的行
+type OrphanPostTag struct {
+	PostID uint `json:"post_id"`
+	TagID  uint `json:"tag_id"`
+}
+
+// 文章已不存在的统计记录，Domain 只用于来源统计
+type OrphanPostStat struct {
+	PostID uint   `json:"post_id"`
+	Day    string `json:"day"`
+	Domain string `json:"domain,omitempty"`
+}
+
+// 文章或系列已不存在的系列成员
+type OrphanSeriesPost struct {
+	SeriesID uint `json:"series_id"`
+	PostID   uint `json:"post_id"`
+}
+
+// 一类问题的数量和示例，示例最多 integritySampleLimit 条
+type IntegrityIssues[T any] struct {
+	Count int64 `json:"count"`
+	Items []T   `json:"items"`
+}
+
+// 数据完整性检查报告
+type IntegrityReport struct {
+	CheckedAt           time.Time                          `json:"checked_at"`
+	LikeCountMismatches IntegrityIssues[LikeCountMismatch] `json:"like_count_mismatches"`
+	OrphanPostLikes     IntegrityIssues[uint]              `json:"orphan_post_likes"` // 文章或用户已不存在的点赞记录ID
+	DuplicateLikes      IntegrityIssues[DuplicateLike]     `json:"duplicate_likes"`
+	OrphanPostTags      IntegrityIssues[OrphanPostTag]     `json:"orphan_post_tags"`
+	OrphanDailyStats    IntegrityIssues[OrphanPostStat]    `json:"orphan_daily_stats"`
+	OrphanReferrerStats IntegrityIssues[OrphanPostStat]    `json:"orphan_referrer_stats"`
+	OrphanSeriesPosts   IntegrityIssues[OrphanSeriesPost]  `json:"orphan_series_posts"`
+	DanglingAuthors     IntegrityIssues[uint]              `json:"dangling_authors"`     // 作者已不存在的文章ID
+	DanglingInvitations IntegrityIssues[uint]              `json:"dangling_invitations"` // 创建者或使用者已不存在的邀请码ID
+	TotalIssues         int64                              `json:"total_issues"`
+	Repaired            bool                               `json:"repaired"`
+}
+
+// 点赞记录对应的有效点赞数：用户和文章都存在，同一用户只算一次
+const actualLikesSQL = `(SELECT COUNT(DISTINCT l.user_id) FROM post_likes l
+	JOIN users u ON u.id = l.user_id WHERE l.post_id = posts.id)`
+
+const (
+	orphanPostLikesWhere = `NOT EXISTS (SELECT 1 FROM posts p WHERE p.id = post_likes.post_id)
+	OR NOT EXISTS (SELECT 1 FROM users u WHERE u.id = post_likes.user_id)`
+	orphanPostTagsWhere = `NOT EXISTS (SELECT 1 FROM posts p WHERE p.id = post_tags.post_id)
+	OR NOT EXISTS (SELECT 1 FROM tags t WHERE t.id = post_tags.tag_id)`
+	// 文章删除后缓冲区中的浏览量写入时会重新创建统计记录
+	orphanDailyStatsWhere    = `NOT EXISTS (SELECT 1 FROM posts p WHERE p.id = post_daily_stats.post_id)`
+	orphanReferrerStatsWhere = `NOT EXISTS (SELECT 1 FROM posts p WHERE p.id = post_referrer_stats.post_id)`
+	orphanSeriesPostsWhere   = `NOT EXISTS (SELECT 1 FROM posts p WHERE p.id = series_posts.post_id)
+	OR NOT EXISTS (SELECT 1 FROM series s WHERE s.id = series_posts.series_id)`
+	danglingAuthorsWhere = `author_id <> 0
+	AND NOT EXISTS (SELECT 1 FROM users u WHERE u.id = posts.author_id)`
+	danglingCreatorWhere = `created_by <> 0
+	AND NOT EXISTS (SELECT 1 FROM users u WHERE u.id = invitations.created_by)`
+	danglingUsedByWhere = `used_by IS NOT NULL
+	AND NOT EXISTS (SELECT 1 FROM users u WHERE u.id = invitations.used_by)`
+)
+
+// 统计数量并取前若干条示例
+func collectIssues[T any](issues *IntegrityIssues[T], count, sample *gorm.DB) error {
+	if err := count.Count(&issues.Count).Error; err != nil {
+		return err
+	}
+	issues.Items = []T{}
+	if issues.Count == 0 {
+		return nil
+	}
+	return sample.Limit(integritySampleLimit).Scan(&issues.Items).Error
+}
+
+// 检查点赞数、孤立的关联记录、重复点赞和指向已删除用户的引用
+func CheckIntegrity(db *gorm.DB) (*IntegrityReport, error) {
+	report := &IntegrityReport{CheckedAt: time.Now()}
+
+	mismatch := func() *gorm.DB {
+		return db.Table("posts").Where("likes <> " + actualLikesSQL)
+	}
+	if err := collectIssues(&report.LikeCountMismatches, mismatch(),
+		mismatch().Select("id AS post_id, likes AS stored, "+actualLikesSQL+" AS actual").Order("id")); err != nil {
+		return nil, fmt.Errorf("检查点赞数失败: %w", err)
+	}
+
+	orphanLikes := func() *gorm.DB {
+		return db.Table("post_likes").Where(orphanPostLikesWhere)
+	}
+	if err := collectIssues(&report.OrphanPostLikes, orphanLikes(),
+		orphanLikes().Select("id").Order("id")); err != nil {
+		return nil, fmt.Errorf("检查孤立点赞记录失败: %w", err)
+	}
+
+	// 重复点赞按 (用户, 文章) 分组统计
+	duplicates := db.Table("post_likes").Select("user_id, post_id, COUNT(*) AS count").
+		Group("user_id, post_id").Having("COUNT(*) > 1")
+	if err := collectIssues(&report.DuplicateLikes, db.Table("(?) AS d", duplicates),
+		db.Table("(?) AS d", duplicates).Order("post_id, user_id")); err != nil {
+		return nil, fmt.Errorf("检查重复点赞失败: %w", err)
+	}
+
+	orphanTags := func() *gorm.DB {
+		return db.Table("post_tags").Where(orphanPostTagsWhere)
+	}
+	if err := collectIssues(&report.OrphanPostTags, orphanTags(),
+		orphanTags().Select("post_id, tag_id").Order("post_id, tag_id")); err != nil {
+		return nil, fmt.Errorf("检查孤立文章标签关联失败: %w", err)
+	}
+
+	dailyStats := func() *gorm.DB {
+		return db.Table("post_daily_stats").Where(orphanDailyStatsWhere)
+	}
+	if err := collectIssues(&report.OrphanDailyStats, dailyStats(),
+		dailyStats().Select("post_id, day").Order("post_id, day")); err != nil {
+		return nil, fmt.Errorf("检查孤立每日统计失败: %w", err)
+	}
+
+	referrerStats := func() *gorm.DB {
+		return db.Table("post_referrer_stats").Where(orphanReferrerStatsWhere)
+	}
+	if err := collectIssues(&report.OrphanReferrerStats, referrerStats(),
+		referrerStats().Select("post_id, day, domain").Order("post_id, day, domain")); err != nil {
+		return nil, fmt.Errorf("检查孤立来源统计失败: %w", err)
+	}
+
+	seriesPosts := func() *gorm.DB {
+		return db.Table("series_posts").Where(orphanSeriesPostsWhere)
+	}
+	if err := collectIssues(&report.OrphanSeriesPosts, seriesPosts(),
+		seriesPosts().Select("series_id, post_id").Order("series_id, post_id")); err != nil {
+		return nil, fmt.Errorf("检查孤立系列文章失败: %w", err)
+	}
+
+	authors := func() *gorm.DB {
+		return db.Table("posts").Where(danglingAuthorsWhere)
+	}
+	if err := collectIssues(&report.DanglingAuthors, authors(),
+		authors().Select("id").Order("id")); err != nil {
+		return nil, fmt.Errorf("检查文章作者失败: %w", err)
+	}
+
+	invitations := func() *gorm.DB {
+		return db.Table("invitations").Where(danglingCreatorWhere).Or(danglingUsedByWhere)
+	}
+	if err := collectIssues(&report.DanglingInvitations, invitations(),
+		invitations().Select("id").Order("id")); err != nil {
+		return nil, fmt.Errorf("检查邀请码失败: %w", err)
+	}
+
+	report.TotalIssues = report.LikeCountMismatches.Count + report.OrphanPostLikes.Count +
+		report.DuplicateLikes.Count + report.OrphanPostTags.Count +
+		report.OrphanDailyStats.Count + report.OrphanReferrerStats.Count + report.OrphanSeriesPosts.Count +
+		report.DanglingAuthors.Count + report.DanglingInvitations.Count
+	return report, nil
+}
+
+// 按点赞记录重新计算文章点赞数，返回修正的文章数
+func recountPostLikes(tx *gorm.DB) (int64, error) {
+	res := tx.Table("posts").Where("likes <> "+actualLikesSQL).
+		UpdateColumn("likes", gorm.Expr(actualLikesSQL))
+	return res.RowsAffected, res.Error
+}
+
+// 在一个事务中修复检查出的问题，返回修复前的检查报告
+// 删除孤立和重复的点赞 (保留最早的一条)、孤立的文章标签关联、统计和系列成员，
+// 作者已删除的文章改为匿名，邀请码中已删除的用户引用置空，最后重新计算点赞数
+func RepairIntegrity() (*IntegrityReport, error) {
+	var report *IntegrityReport
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if report, err = CheckIntegrity(tx); err != nil {
+			return err
+		}
+		if report.TotalIssues == 0 {
+			return nil
+		}
+
+		steps := []struct {
+			name string
+			run  func() error
+		}{
+			{"删除孤立点赞记录", func() error {
+				return tx.Exec("DELETE FROM post_likes WHERE " + orphanPostLikesWhere).Error
+			}},
+			// MySQL 不允许在子查询中直接引用要删除的表，多包一层派生表
+			{"删除重复点赞", func() error {
+				return tx.Exec(`DELETE FROM post_likes WHERE id NOT IN (
+					SELECT id FROM (SELECT MIN(id) AS id FROM post_likes GROUP BY user_id, post_id) AS keep_likes)`).Error
+			}},
+			{"删除孤立文章标签关联", func() error {
+				return tx.Exec("DELETE FROM post_tags WHERE " + orphanPostTagsWhere).Error
+			}},
+			{"删除孤立每日统计", func() error {
+				return tx.Exec("DELETE FROM post_daily_stats WHERE " + orphanDailyStatsWhere).Error
+			}},
+			{"删除孤立来源统计", func() error {
+				return tx.Exec("DELETE FROM post_referrer_stats WHERE " + orphanReferrerStatsWhere).Error
+			}},
+			{"删除孤立系列文章", func() error {
+				return tx.Exec("DELETE FROM series_posts WHERE " + orphanSeriesPostsWhere).Error
+			}},
+			{"匿名化文章作者", func() error {
+				return tx.Exec("UPDATE posts SET author_id = 0 WHERE " + danglingAuthorsWhere).Error
+			}},
+			{"修复邀请码创建者", func() error {
+				return tx.Exec("UPDATE invitations SET created_by = 0 WHERE " + danglingCreatorWhere).Error
+			}},
+			// 使用时间保留，邀请码仍视为已使用
+			{"修复邀请码使用者", func() error {
+				return tx.Exec("UPDATE invitations SET used_by = NULL WHERE " + danglingUsedByWhere).Error
+			}},
+			{"重新计算点赞数", func() error {
+				_, err := recountPostLikes(tx)
+				return err
+			}},
+		}
+		for _, step := range steps {
+			if err := step.run(); err != nil {
+				return fmt.Errorf("%s失败: %w", step.name, err)
+			}
+		}
+		report.Repaired = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// 检查数据完整性 (仅管理员可用)
+func GetIntegrityReport(c *gin.Context) {
+	// 验证管理员权限
+	if !isAdmin(c) {
+		return
+	}
+
+	report, err := CheckIntegrity(models.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// 修复数据完整性问题 (仅管理员可用)
+func RepairIntegrityIssues(c *gin.Context) {
+	// 验证管理员权限
+	if !isAdmin(c) {
+		return
+	}
+
+	report, err := RepairIntegrity()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "修复失败，数据未改动: " + err.Error()})
+		return
+	}
+
+	message := "未发现问题"
+	if report.Repaired {
+		message = fmt.Sprintf("已修复 %d 个问题", report.TotalIssues)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "report": report})
+}
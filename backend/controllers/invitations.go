@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errInvitationUsed = errors.New("邀请码已被使用")
+
+// 校验邀请码，不可用时返回nil和错误信息
+func validateInvitation(code, email string) (*models.Invitation, string) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, "需要邀请码才能注册"
+	}
+
+	var invitation models.Invitation
+	if err := models.DB.Where("code = ?", code).First(&invitation).Error; err != nil {
+		return nil, "邀请码无效"
+	}
+
+	if !invitation.IsUsable() {
+		return nil, "邀请码已失效"
+	}
+
+	if invitation.Email != "" && !strings.EqualFold(invitation.Email, strings.TrimSpace(email)) {
+		return nil, "邀请码与注册邮箱不匹配"
+	}
+
+	return &invitation, ""
+}
+
+// 标记邀请码已使用，并发注册时只有一个能成功
+func redeemInvitation(tx *gorm.DB, invitation *models.Invitation, userID uint) error {
+	now := time.Now()
+	result := tx.Model(&models.Invitation{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", invitation.ID).
+		Updates(map[string]interface{}{"used_by": userID, "used_at": &now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errInvitationUsed
+	}
+	return nil
+}
+
+// 获取邀请码列表 (仅管理员可用)
+func GetInvitations(c *gin.Context) {
+	invitations := []models.Invitation{}
+	if err := models.DB.Order("id desc").Find(&invitations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取邀请码失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// 创建邀请码 (仅管理员可用)
+func CreateInvitation(c *gin.Context) {
+	var invitationData struct {
+		Email          string `json:"email"`
+		ExpiresInHours int    `json:"expires_in_hours"` // 0 表示不过期
+	}
+
+	if err := c.ShouldBindJSON(&invitationData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	email := strings.TrimSpace(invitationData.Email)
+	if email != "" {
+		// 只接受纯地址，注册时按地址比较
+		addr, err := mail.ParseAddress(email)
+		if err != nil || addr.Address != email {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的邮箱地址"})
+			return
+		}
+	}
+
+	code, err := utils.RandomToken(12)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成邀请码失败"})
+		return
+	}
+
+	invitation := models.Invitation{
+		Code:      code,
+		Email:     email,
+		CreatedBy: c.GetUint("userID"),
+	}
+	if invitationData.ExpiresInHours > 0 {
+		expiresAt := time.Now().Add(time.Duration(invitationData.ExpiresInHours) * time.Hour)
+		invitation.ExpiresAt = &expiresAt
+	}
+
+	if err := models.DB.Create(&invitation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建邀请码失败"})
+		return
+	}
+
+	// 绑定邮箱的邀请码直接发送给对方
+	if email != "" {
+		link := fmt.Sprintf("%s/register?invite=%s", config.AppConfig.SiteURL, code)
+		body := fmt.Sprintf("你收到了一份博客注册邀请，请打开以下链接完成注册：\n%s\n", link)
+		if err := utils.SendMail(email, "博客注册邀请", body); err != nil {
+			c.JSON(http.StatusCreated, gin.H{"invitation": invitation, "warning": "发送邀请邮件失败: " + err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"invitation": invitation})
+}
+
+// 撤销邀请码 (仅管理员可用)
+func RevokeInvitation(c *gin.Context) {
+	var invitation models.Invitation
+	if err := models.DB.First(&invitation, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "邀请码不存在"})
+		return
+	}
+
+	if invitation.UsedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "邀请码已被使用，无法撤销"})
+		return
+	}
+
+	now := time.Now()
+	if err := models.DB.Model(&invitation).Update("revoked_at", &now).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "撤销邀请码失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "邀请码已撤销"})
+}
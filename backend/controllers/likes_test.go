@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"blog-backend/models"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 使用内存SQLite初始化数据库，只保留一个连接，所有请求共用同一个内存库
+func setupLikeTestDB(t *testing.T) {
+	db, err := models.OpenDB("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取连接失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := models.MigrateSchema(db); err != nil {
+		t.Fatalf("数据库迁移失败: %v", err)
+	}
+	models.DB = db
+}
+
+// 点赞接口的路由，用请求头 X-User-ID 代替登录
+func likeTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	auth := r.Group("/", func(c *gin.Context) {
+		id, _ := strconv.ParseUint(c.GetHeader("X-User-ID"), 10, 64)
+		c.Set("userID", uint(id))
+	})
+	auth.PUT("/posts/:id/like", PutPostLike)
+	auth.DELETE("/posts/:id/like", DeletePostLike)
+	return r
+}
+
+// 并发发送点赞和取消点赞请求后，点赞数必须与点赞记录数一致
+func TestConcurrentPostLikes(t *testing.T) {
+	setupLikeTestDB(t)
+
+	const users = 10
+	const requestsPerUser = 20
+
+	post := models.Post{Title: "点赞测试", Slug: "like-test"}
+	if err := models.DB.Create(&post).Error; err != nil {
+		t.Fatalf("创建文章失败: %v", err)
+	}
+	userIDs := make([]uint, users)
+	for i := range userIDs {
+		user := models.User{Username: fmt.Sprintf("user%d", i), Password: "x", UserType: models.UserTypeRegular}
+		if err := models.DB.Create(&user).Error; err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+		userIDs[i] = user.ID
+	}
+
+	r := likeTestRouter()
+	path := fmt.Sprintf("/posts/%d/like", post.ID)
+
+	var wg sync.WaitGroup
+	errs := make(chan string, users*requestsPerUser)
+	for i, userID := range userIDs {
+		// 每个用户的请求也并发发送，模拟重复点击和网络重试
+		rng := rand.New(rand.NewSource(int64(i)))
+		for j := 0; j < requestsPerUser; j++ {
+			method := http.MethodPut
+			if rng.Intn(2) == 0 {
+				method = http.MethodDelete
+			}
+			wg.Add(1)
+			go func(userID uint, method string) {
+				defer wg.Done()
+				req := httptest.NewRequest(method, path, nil)
+				req.Header.Set("X-User-ID", strconv.FormatUint(uint64(userID), 10))
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				if w.Code != http.StatusOK {
+					errs <- fmt.Sprintf("%s 返回 %d: %s", method, w.Code, w.Body.String())
+				}
+			}(userID, method)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	var likes int
+	if err := models.DB.Model(&models.Post{}).Where("id = ?", post.ID).Pluck("likes", &likes).Error; err != nil {
+		t.Fatalf("读取点赞数失败: %v", err)
+	}
+	var count int64
+	if err := models.DB.Model(&models.PostLike{}).Where("post_id = ?", post.ID).Count(&count).Error; err != nil {
+		t.Fatalf("统计点赞记录失败: %v", err)
+	}
+	if int64(likes) != count {
+		t.Fatalf("点赞数 %d 与点赞记录数 %d 不一致", likes, count)
+	}
+
+	var duplicates int64
+	models.DB.Raw("SELECT COUNT(*) FROM (SELECT user_id FROM post_likes GROUP BY user_id, post_id HAVING COUNT(*) > 1) AS d").
+		Scan(&duplicates)
+	if duplicates > 0 {
+		t.Fatalf("存在 %d 组重复的点赞记录", duplicates)
+	}
+}
@@ -0,0 +1,589 @@
+package controllers
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// 导出的 front matter，字段兼容 Hugo (draft) 和 Jekyll (published)
+type markdownFrontMatter struct {
+	ID        uint      `yaml:"id"`
+	Title     string    `yaml:"title"`
+	Slug      string    `yaml:"slug"`
+	Date      time.Time `yaml:"date"`
+	Lastmod   time.Time `yaml:"lastmod"`
+	Tags      []string  `yaml:"tags"`
+	Summary   string    `yaml:"summary,omitempty"`
+	Cover     string    `yaml:"cover,omitempty"`
+	Published bool      `yaml:"published"`
+	Draft     bool      `yaml:"draft"`
+}
+
+// 导入时读取的 front matter，同时接受 Hugo 和 Jekyll 常用的字段名
+type markdownFrontMatterInput struct {
+	ID          uint        `yaml:"id"`
+	Title       string      `yaml:"title"`
+	Slug        string      `yaml:"slug"`
+	Date        string      `yaml:"date"`
+	Lastmod     string      `yaml:"lastmod"`
+	Tags        interface{} `yaml:"tags"`
+	Categories  interface{} `yaml:"categories"`
+	Summary     string      `yaml:"summary"`
+	Description string      `yaml:"description"`
+	Excerpt     string      `yaml:"excerpt"`
+	Cover       interface{} `yaml:"cover"` // 字符串，或 Hugo 主题常用的 {image: ...}
+	Image       string      `yaml:"image"`
+	Published   *bool       `yaml:"published"`
+	Draft       *bool       `yaml:"draft"`
+}
+
+// 解析后的 Markdown 文章
+type markdownPost struct {
+	File      string
+	ID        uint
+	Slug      string
+	Title     string
+	Content   string
+	Summary   string
+	Cover     string
+	Published bool
+	Tags      []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// 支持的日期格式，包括 Jekyll 的 "2006-01-02 15:04:05 -0700"
+var markdownDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Jekyll 文件名中的日期前缀
+var jekyllDatePrefixRe = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}-`)
+
+func parseMarkdownDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range markdownDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法识别的日期: %s", s)
+}
+
+// 标签可以是列表，也可以是 Jekyll 风格的空格或逗号分隔字符串
+func markdownStringList(v interface{}) []string {
+	var list []string
+	switch value := v.(type) {
+	case string:
+		list = strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })
+	case []interface{}:
+		for _, item := range value {
+			list = append(list, fmt.Sprint(item))
+		}
+	}
+	return list
+}
+
+// 根据文件名推断slug：Jekyll 去掉日期前缀，Hugo 页面包 (index.md) 使用目录名
+func markdownSlugFromFile(name string) string {
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	if base == "index" || base == "_index" {
+		base = path.Base(path.Dir(name))
+	}
+	return utils.Slugify(jekyllDatePrefixRe.ReplaceAllString(base, ""))
+}
+
+// 拆分 YAML front matter 和正文
+func splitFrontMatter(data []byte) ([]byte, string, error) {
+	text := strings.ReplaceAll(string(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))), "\r\n", "\n")
+	const delimiter = "---\n"
+	if !strings.HasPrefix(text, delimiter) {
+		return nil, "", errors.New("缺少 YAML front matter")
+	}
+
+	offset := len(delimiter)
+	for _, line := range strings.SplitAfter(text[offset:], "\n") {
+		if strings.TrimRight(line, " \t\n") == "---" {
+			header := text[len(delimiter):offset]
+			body := text[offset+len(line):]
+			return []byte(header), strings.TrimLeft(body, "\n"), nil
+		}
+		offset += len(line)
+	}
+	return nil, "", errors.New("front matter 没有结束标记")
+}
+
+// 解析一个 Markdown 文件
+func parseMarkdownPost(name string, data []byte) (*markdownPost, error) {
+	header, body, err := splitFrontMatter(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var fm markdownFrontMatterInput
+	if err := yaml.Unmarshal(header, &fm); err != nil {
+		return nil, fmt.Errorf("解析 front matter 失败: %w", err)
+	}
+
+	post := &markdownPost{
+		File:      name,
+		ID:        fm.ID,
+		Title:     strings.TrimSpace(fm.Title),
+		Content:   strings.TrimRight(body, "\n"),
+		Published: true,
+	}
+	if post.Title == "" {
+		return nil, errors.New("缺少标题")
+	}
+
+	post.Slug = utils.Slugify(fm.Slug)
+	if post.Slug == "" {
+		post.Slug = markdownSlugFromFile(name)
+	}
+
+	if post.CreatedAt, err = parseMarkdownDate(fm.Date); err != nil {
+		return nil, err
+	}
+	if post.UpdatedAt, err = parseMarkdownDate(fm.Lastmod); err != nil {
+		return nil, err
+	}
+
+	for _, summary := range []string{fm.Summary, fm.Description, fm.Excerpt} {
+		if summary = strings.TrimSpace(summary); summary != "" {
+			post.Summary = summary
+			break
+		}
+	}
+
+	switch cover := fm.Cover.(type) {
+	case string:
+		post.Cover = cover
+	case map[string]interface{}:
+		if image, ok := cover["image"].(string); ok {
+			post.Cover = image
+		}
+	}
+	if post.Cover == "" {
+		post.Cover = fm.Image
+	}
+
+	if fm.Published != nil {
+		post.Published = *fm.Published
+	} else if fm.Draft != nil {
+		post.Published = !*fm.Draft
+	}
+
+	seen := make(map[string]bool)
+	for _, tag := range append(markdownStringList(fm.Tags), markdownStringList(fm.Categories)...) {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			post.Tags = append(post.Tags, tag)
+		}
+	}
+
+	return post, nil
+}
+
+// 生成文章的 Markdown 文件内容
+func renderMarkdownPost(post *models.Post, slug string) ([]byte, error) {
+	fm := markdownFrontMatter{
+		ID:        post.ID,
+		Title:     post.Title,
+		Slug:      slug,
+		Date:      post.CreatedAt.Truncate(time.Second),
+		Lastmod:   post.UpdatedAt.Truncate(time.Second),
+		Tags:      []string{},
+		Summary:   post.Summary,
+		Cover:     post.CoverImage,
+		Published: post.Published,
+		Draft:     !post.Published,
+	}
+	for _, tag := range post.Tags {
+		fm.Tags = append(fm.Tags, tag.Name)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("---\n")
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(fm); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	b.WriteString("---\n\n")
+	b.WriteString(strings.TrimRight(post.Content, "\n"))
+	b.WriteString("\n")
+	return b.Bytes(), nil
+}
+
+// 把所有文章写成 {slug}.md 文件，返回写出的文件数
+func writeMarkdownPosts(db *gorm.DB, aw archiveWriter) (int, error) {
+	used := make(map[string]bool)
+	count := 0
+	var batch []models.Post
+	err := db.Preload("Tags").FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			post := &batch[i]
+			slug := post.Slug
+			if slug == "" {
+				slug = utils.Slugify(post.Title)
+			}
+			if slug == "" {
+				slug = fmt.Sprintf("post-%d", post.ID)
+			}
+
+			name := slug + ".md"
+			if used[name] {
+				name = fmt.Sprintf("%s-%d.md", slug, post.ID)
+			}
+			used[name] = true
+
+			data, err := renderMarkdownPost(post, slug)
+			if err != nil {
+				return err
+			}
+			if err := aw.addFile(name, int64(len(data)), post.UpdatedAt, bytes.NewReader(data)); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	}).Error
+	return count, err
+}
+
+// 把请求中的目录解析为 IMPORT_PATH 下的路径
+func importPathDir(dir string) (string, bool) {
+	rel := safeRelPath(dir)
+	if rel == "" {
+		return "", false
+	}
+	return filepath.Join(config.AppConfig.ImportPath, filepath.FromSlash(rel)), true
+}
+
+// 导出所有文章为 Markdown 文件压缩包 (仅管理员可用)，format=zip (默认) 或 tar.gz
+func ExportMarkdown(c *gin.Context) {
+	// 验证管理员权限
+	if !isAdmin(c) {
+		return
+	}
+
+	format := c.DefaultQuery("format", "zip")
+	var contentType string
+	switch format {
+	case "zip":
+		contentType = "application/zip"
+	case "tar.gz":
+		contentType = "application/gzip"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的压缩格式"})
+		return
+	}
+
+	filename := fmt.Sprintf("blog_markdown_%s.%s", time.Now().Format("20060102_150405"), format)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+
+	aw := newArchiveWriter(format, c.Writer)
+	if _, err := writeMarkdownPosts(models.DB, aw); err != nil {
+		log.Printf("导出Markdown失败: %v", err)
+		c.Abort()
+		return
+	}
+	if err := aw.close(); err != nil {
+		log.Printf("导出Markdown失败: %v", err)
+		c.Abort()
+	}
+}
+
+// 导出所有文章到服务器本地目录 (仅管理员可用)，目录位于 IMPORT_PATH 下，例如静态站点的 content/posts
+func ExportMarkdownToDir(c *gin.Context) {
+	// 验证管理员权限
+	if !isAdmin(c) {
+		return
+	}
+
+	var exportData struct {
+		Dir string `json:"dir" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&exportData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	dir, ok := importPathDir(exportData.Dir)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的目录"})
+		return
+	}
+
+	count, err := writeMarkdownPosts(models.DB, &dirWriter{root: dir})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导出Markdown失败: " + err.Error(), "files": count})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "导出成功", "files": count})
+}
+
+// 读取目录中所有 .md 文件，返回相对路径 -> 内容
+func readMarkdownDir(root string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".md") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	return files, err
+}
+
+// 查找或创建标签
+func findOrCreateTag(tx *gorm.DB, name string, report *EntityReport) (models.Tag, error) {
+	var tag models.Tag
+	if err := tx.Where("name = ?", name).First(&tag).Error; err == nil {
+		return tag, nil
+	}
+	tag = models.Tag{Name: name}
+	if err := tx.Create(&tag).Error; err != nil {
+		return tag, err
+	}
+	report.Created++
+	return tag, nil
+}
+
+// Markdown 导入报告
+type MarkdownImportReport struct {
+	DryRun bool          `json:"dry_run"`
+	Posts  *EntityReport `json:"posts"`
+	Tags   *EntityReport `json:"tags"`
+}
+
+// 按ID或slug更新已有文章，找不到时新建
+func upsertMarkdownPost(tx *gorm.DB, post *markdownPost, authorID uint, report *MarkdownImportReport) error {
+	var existing models.Post
+	found := false
+	if post.ID != 0 && tx.First(&existing, post.ID).Error == nil {
+		found = true
+	} else if post.Slug != "" && tx.Where("slug = ?", post.Slug).First(&existing).Error == nil {
+		found = true
+	}
+
+	if found && post.Slug != "" && postSlugTaken(tx, post.Slug, existing.ID) {
+		report.Posts.skip("%s: slug %s 已被其他文章使用", post.File, post.Slug)
+		return nil
+	}
+
+	var tags []models.Tag
+	for _, name := range post.Tags {
+		tag, err := findOrCreateTag(tx, name, report.Tags)
+		if err != nil {
+			return fmt.Errorf("创建标签失败: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if found {
+		updates := map[string]interface{}{
+			"title":       post.Title,
+			"content":     post.Content,
+			"summary":     post.Summary,
+			"cover_image": post.Cover,
+			"published":   post.Published,
+		}
+		if post.Slug != "" {
+			updates["slug"] = post.Slug
+		}
+		if !post.CreatedAt.IsZero() {
+			updates["created_at"] = post.CreatedAt
+		}
+		if !post.UpdatedAt.IsZero() {
+			updates["updated_at"] = post.UpdatedAt
+		}
+		if err := tx.Model(&existing).Updates(updates).Error; err != nil {
+			return fmt.Errorf("更新文章失败: %w", err)
+		}
+		if err := tx.Model(&existing).Association("Tags").Replace(tags); err != nil {
+			return fmt.Errorf("更新标签失败: %w", err)
+		}
+		report.Posts.Updated++
+		return nil
+	}
+
+	created := models.Post{
+		Title:      post.Title,
+		Slug:       post.Slug,
+		Content:    post.Content,
+		Summary:    post.Summary,
+		CoverImage: post.Cover,
+		Published:  post.Published,
+		AuthorID:   authorID,
+		CreatedAt:  post.CreatedAt,
+		UpdatedAt:  post.UpdatedAt,
+		Tags:       tags,
+	}
+	if err := preparePostSlug(tx, &created); err != nil {
+		return fmt.Errorf("生成文章slug失败: %w", err)
+	}
+	if err := tx.Create(&created).Error; err != nil {
+		return fmt.Errorf("创建文章失败: %w", err)
+	}
+	if err := assignPostSlug(tx, &created); err != nil {
+		return fmt.Errorf("生成文章slug失败: %w", err)
+	}
+	report.Posts.Created++
+	return nil
+}
+
+// 导入 Markdown 文章 (仅管理员可用)
+// 上传单个 .md 文件或 .zip/.tar.gz 压缩包，或通过 dir 指定 IMPORT_PATH 下的目录
+func ImportMarkdown(c *gin.Context) {
+	// 验证管理员权限
+	if !isAdmin(c) {
+		return
+	}
+
+	var files map[string][]byte
+	if dirParam := c.DefaultPostForm("dir", c.Query("dir")); dirParam != "" {
+		dir, ok := importPathDir(dirParam)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的目录"})
+			return
+		}
+		var err error
+		if files, err = readMarkdownDir(dir); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "读取目录失败: " + err.Error()})
+			return
+		}
+	} else {
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请上传文件或指定目录"})
+			return
+		}
+		defer file.Close()
+
+		name := strings.ToLower(header.Filename)
+		switch {
+		case strings.HasSuffix(name, ".md"):
+			var buf bytes.Buffer
+			if _, err := buf.ReadFrom(file); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "读取文件失败: " + err.Error()})
+				return
+			}
+			files = map[string][]byte{header.Filename: buf.Bytes()}
+		case strings.HasSuffix(name, ".zip"), strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+			tmp, err := os.MkdirTemp("", "blog_markdown_*")
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "创建临时目录失败"})
+				return
+			}
+			defer os.RemoveAll(tmp)
+			if err := unpackArchive(tmp, file, file, header.Size, strings.HasSuffix(name, ".zip")); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "解压文件失败: " + err.Error()})
+				return
+			}
+			if files, err = readMarkdownDir(tmp); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "读取文件失败: " + err.Error()})
+				return
+			}
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "只支持 .md 文件或 .zip/.tar.gz 压缩包"})
+			return
+		}
+	}
+
+	options := parseImportOptions(c)
+	report := &MarkdownImportReport{
+		DryRun: options.DryRun,
+		Posts:  newEntityReport(len(files)),
+		Tags:   newEntityReport(0),
+	}
+
+	// 按文件名排序，保证导入顺序稳定
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tx := models.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	authorID := c.GetUint("userID")
+	for _, name := range names {
+		post, err := parseMarkdownPost(name, files[name])
+		if err != nil {
+			report.Posts.skip("%s: %s", name, err.Error())
+			continue
+		}
+		if err := upsertMarkdownPost(tx, post, authorID, report); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": name + ": " + err.Error(), "report": report})
+			return
+		}
+	}
+	report.Tags.Total = report.Tags.Created
+
+	// 试运行：回滚并返回报告
+	if options.DryRun {
+		tx.Rollback()
+		c.JSON(http.StatusOK, gin.H{"message": "试运行完成，未写入任何数据", "report": report})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "提交事务失败: " + err.Error()})
+		return
+	}
+	invalidateRelatedPosts()
+
+	c.JSON(http.StatusOK, gin.H{"message": "导入成功", "report": report})
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"blog-backend/models"
+	"blog-backend/utils"
+	"fmt"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 手动指定的slug只能包含小写字母、数字和连字符
+func validPostSlug(slug string) bool {
+	return slug != "" && utils.Slugify(slug) == slug
+}
+
+// slug 是否已被其他文章使用
+func postSlugTaken(tx *gorm.DB, slug string, exceptID uint) bool {
+	var count int64
+	tx.Model(&models.Post{}).Where("slug = ? AND id <> ?", slug, exceptID).Count(&count)
+	return count > 0
+}
+
+// 生成不重复的slug，被占用时依次追加 -2、-3…；base 为空时使用 post-{id}
+func uniquePostSlug(tx *gorm.DB, base string, postID uint) string {
+	if base == "" {
+		base = fmt.Sprintf("post-%d", postID)
+	}
+	slug := base
+	for i := 2; postSlugTaken(tx, slug, postID); i++ {
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+	return slug
+}
+
+// 临时slug的前缀，含下划线，不会与正常的slug重复
+const pendingSlugPrefix = "_pending-"
+
+// 新建文章前确定slug：指定的slug被占用时追加序号，未指定时根据标题生成
+// slug 有唯一索引，标题也无法生成slug时 (post-{id} 需要文章ID) 先写入随机的临时slug，创建后由 assignPostSlug 替换
+func preparePostSlug(tx *gorm.DB, post *models.Post) error {
+	base := post.Slug
+	if base == "" {
+		base = utils.Slugify(post.Title)
+	}
+	if base != "" {
+		post.Slug = uniquePostSlug(tx, base, 0)
+		return nil
+	}
+	token, err := utils.RandomToken(8)
+	if err != nil {
+		return err
+	}
+	post.Slug = pendingSlugPrefix + token
+	return nil
+}
+
+// 为新建的文章补充slug，替换临时slug，不修改更新时间
+func assignPostSlug(tx *gorm.DB, post *models.Post) error {
+	pending := strings.HasPrefix(post.Slug, pendingSlugPrefix)
+	if post.Slug != "" && !pending && !postSlugTaken(tx, post.Slug, post.ID) {
+		return nil
+	}
+	base := post.Slug
+	if base == "" || pending {
+		base = utils.Slugify(post.Title)
+	}
+	post.Slug = uniquePostSlug(tx, base, post.ID)
+	return tx.Model(post).UpdateColumn("slug", post.Slug).Error
+}
+
+// 为没有slug的旧文章生成slug
+func BackfillPostSlugs() {
+	var posts []models.Post
+	if err := models.DB.Where("slug = ? OR slug IS NULL", "").Find(&posts).Error; err != nil {
+		log.Printf("查询缺少slug的文章失败: %v", err)
+		return
+	}
+	for i := range posts {
+		if err := assignPostSlug(models.DB, &posts[i]); err != nil {
+			log.Printf("为文章 %d 生成slug失败: %v", posts[i].ID, err)
+		}
+	}
+	if len(posts) > 0 {
+		log.Printf("已为 %d 篇文章生成slug", len(posts))
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // 获取所有博客文章（分页）
@@ -72,6 +74,8 @@ func GetPosts(c *gin.Context) {
 		orderByClause = "view_count DESC"
 	case "likes":
 		orderByClause = "likes DESC"
+	case "trending":
+		orderByClause = "trending_score DESC, created_at DESC"
 	case "created_at":
 		orderByClause = "created_at DESC"
 	}
@@ -101,8 +105,13 @@ func GetPost(c *gin.Context) {
 		return
 	}
 
-	// 增加浏览量
-	models.DB.Model(&post).Update("view_count", post.ViewCount+1)
+	// 记录浏览量，先累计在内存中定期写入数据库，返回的浏览量包含尚未写入的部分
+	post.ViewCount += int(views.record(c, post.ID))
+
+	// 附带所属系列和前后篇
+	if info, err := postSeriesInfo(&post); err == nil {
+		post.Series = info
+	}
 
 	c.JSON(http.StatusOK, post)
 }
@@ -111,6 +120,7 @@ func GetPost(c *gin.Context) {
 func CreatePost(c *gin.Context) {
 	var postData struct {
 		Title      string   `json:"title" binding:"required"`
+		Slug       string   `json:"slug"` // 为空时根据标题生成
 		Content    string   `json:"content" binding:"required"`
 		Summary    string   `json:"summary"`
 		CoverImage string   `json:"cover_image"`
@@ -123,23 +133,48 @@ func CreatePost(c *gin.Context) {
 		return
 	}
 
+	if postData.Slug != "" {
+		if !validPostSlug(postData.Slug) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "slug只能包含小写字母、数字和连字符"})
+			return
+		}
+		if postSlugTaken(models.DB, postData.Slug, 0) {
+			c.JSON(http.StatusConflict, gin.H{"error": "slug已被其他文章使用"})
+			return
+		}
+	}
+
 	post := models.Post{
 		Title:      postData.Title,
+		Slug:       postData.Slug,
 		Content:    postData.Content,
 		Summary:    postData.Summary,
 		CoverImage: postData.CoverImage,
 		Published:  postData.Published,
+		AuthorID:   c.GetUint("userID"),
 	}
 
 	// 开始事务
 	tx := models.DB.Begin()
 
+	if err := preparePostSlug(tx, &post); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建文章失败"})
+		return
+	}
+
 	if err := tx.Create(&post).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建文章失败"})
 		return
 	}
 
+	if err := assignPostSlug(tx, &post); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建文章失败"})
+		return
+	}
+
 	// 处理标签
 	if len(postData.TagNames) > 0 {
 		var tags []models.Tag
@@ -166,6 +201,7 @@ func CreatePost(c *gin.Context) {
 	}
 
 	tx.Commit()
+	invalidateRelatedPosts()
 
 	// 重新查询包含标签的文章
 	models.DB.Preload("Tags").First(&post, post.ID)
@@ -185,6 +221,7 @@ func UpdatePost(c *gin.Context) {
 
 	var postData struct {
 		Title      string   `json:"title"`
+		Slug       *string  `json:"slug"` // 不传时保持不变
 		Content    string   `json:"content"`
 		Summary    string   `json:"summary"`
 		CoverImage string   `json:"cover_image"`
@@ -197,9 +234,6 @@ func UpdatePost(c *gin.Context) {
 		return
 	}
 
-	// 开始事务
-	tx := models.DB.Begin()
-
 	// 更新文章信息
 	updates := map[string]interface{}{
 		"title":       postData.Title,
@@ -209,6 +243,21 @@ func UpdatePost(c *gin.Context) {
 		"published":   postData.Published,
 	}
 
+	if postData.Slug != nil {
+		if !validPostSlug(*postData.Slug) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "slug只能包含小写字母、数字和连字符"})
+			return
+		}
+		if postSlugTaken(models.DB, *postData.Slug, post.ID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "slug已被其他文章使用"})
+			return
+		}
+		updates["slug"] = *postData.Slug
+	}
+
+	// 开始事务
+	tx := models.DB.Begin()
+
 	if err := tx.Model(&post).Updates(updates).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新文章失败"})
@@ -239,6 +288,7 @@ func UpdatePost(c *gin.Context) {
 	}
 
 	tx.Commit()
+	invalidateRelatedPosts()
 
 	// 重新查询包含标签的文章
 	models.DB.Preload("Tags").First(&post, post.ID)
@@ -256,85 +306,148 @@ func DeletePost(c *gin.Context) {
 		return
 	}
 
-	if err := models.DB.Delete(&post).Error; err != nil {
+	// 同时删除点赞记录、标签关联、统计数据和系列成员，避免留下孤立数据
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("post_id = ?", post.ID).Delete(&models.PostLike{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&post).Association("Tags").Clear(); err != nil {
+			return err
+		}
+		for _, stat := range []interface{}{&models.PostDailyStat{}, &models.PostReferrerStat{}, &models.SeriesPost{}} {
+			if err := tx.Where("post_id = ?", post.ID).Delete(stat).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&post).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除文章失败"})
 		return
 	}
 
+	invalidateRelatedPosts()
+
 	c.JSON(http.StatusOK, gin.H{"message": "文章删除成功"})
 }
 
-// 点赞或取消点赞博客文章
-func LikePost(c *gin.Context) {
-	id := c.Param("id")
+// 设置点赞状态，返回最新的点赞数以及本次是否改变了状态
+// 依靠唯一索引保证每个用户只有一条点赞记录，点赞数用 likes = likes ± 1 原子更新，
+// 只有真正插入或删除了点赞记录时才修改点赞数，重复请求不会影响计数
+func setPostLike(userID, postID uint, liked bool) (int, bool, error) {
+	var likes int
+	var changed bool
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		var res *gorm.DB
+		delta := "likes + 1"
+		if liked {
+			res = tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.PostLike{UserID: userID, PostID: postID})
+		} else {
+			res = tx.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&models.PostLike{})
+			delta = "CASE WHEN likes > 0 THEN likes - 1 ELSE 0 END"
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+
+		changed = res.RowsAffected > 0
+		if changed {
+			if err := tx.Model(&models.Post{}).Where("id = ?", postID).
+				UpdateColumn("likes", gorm.Expr(delta)).Error; err != nil {
+				return err
+			}
+			if err := recordPostLikeStat(tx, postID, liked); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Post{}).Where("id = ?", postID).Pluck("likes", &likes).Error
+	})
+	return likes, changed, err
+}
+
+// 查找要点赞的文章，失败时已写入响应
+func findLikeTarget(c *gin.Context) (uint, models.Post, bool) {
 	userID := c.GetUint("userID")
 
 	// 检查用户是否登录
 	if userID == 0 {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "请先登录"})
-		return
+		return 0, models.Post{}, false
 	}
 
 	var post models.Post
-	if err := models.DB.First(&post, id).Error; err != nil {
+	if err := models.DB.Select("id").First(&post, c.Param("id")).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
-		return
+		return 0, models.Post{}, false
 	}
+	return userID, post, true
+}
 
-	// 检查用户是否已点赞
-	var postLike models.PostLike
-	result := models.DB.Where("user_id = ? AND post_id = ?", userID, post.ID).First(&postLike)
+// 点赞或取消点赞博客文章
+// 保留切换语义兼容旧客户端，新客户端应使用幂等的 PUT/DELETE 接口
+func LikePost(c *gin.Context) {
+	userID, post, ok := findLikeTarget(c)
+	if !ok {
+		return
+	}
 
-	// 开始事务
-	tx := models.DB.Begin()
+	// 先尝试取消点赞，没有点赞记录时再点赞
+	likes, removed, err := setPostLike(userID, post.ID, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "取消点赞失败"})
+		return
+	}
+	if removed {
+		c.JSON(http.StatusOK, gin.H{"message": "取消点赞成功", "likes": likes, "liked": false})
+		return
+	}
 
-	if result.Error != nil { // 未点赞，添加点赞
-		postLike = models.PostLike{
-			UserID: userID,
-			PostID: post.ID,
-		}
-		if err := tx.Create(&postLike).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "点赞失败"})
-			return
-		}
+	if likes, _, err = setPostLike(userID, post.ID, true); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "点赞失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "点赞成功", "likes": likes, "liked": true})
+}
 
-		// 增加点赞数
-		if err := tx.Model(&post).Update("likes", post.Likes+1).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "点赞失败"})
-			return
-		}
+// 点赞博客文章，已点赞时不做改动
+func PutPostLike(c *gin.Context) {
+	userID, post, ok := findLikeTarget(c)
+	if !ok {
+		return
+	}
 
-		tx.Commit()
+	likes, changed, err := setPostLike(userID, post.ID, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "点赞失败"})
+		return
+	}
 
-		c.JSON(http.StatusOK, gin.H{
-			"message": "点赞成功",
-			"likes":   post.Likes + 1,
-			"liked":   true,
-		})
-	} else { // 已点赞，取消点赞
-		if err := tx.Delete(&postLike).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "取消点赞失败"})
-			return
-		}
+	message := "点赞成功"
+	if !changed {
+		message = "已点赞"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "likes": likes, "liked": true})
+}
 
-		// 减少点赞数
-		if err := tx.Model(&post).Update("likes", post.Likes-1).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "取消点赞失败"})
-			return
-		}
+// 取消点赞博客文章，未点赞时不做改动
+func DeletePostLike(c *gin.Context) {
+	userID, post, ok := findLikeTarget(c)
+	if !ok {
+		return
+	}
 
-		tx.Commit()
+	likes, changed, err := setPostLike(userID, post.ID, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "取消点赞失败"})
+		return
+	}
 
-		c.JSON(http.StatusOK, gin.H{
-			"message": "取消点赞成功",
-			"likes":   post.Likes - 1,
-			"liked":   false,
-		})
+	message := "取消点赞成功"
+	if !changed {
+		message = "未点赞"
 	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "likes": likes, "liked": false})
 }
 
 // 检查用户是否已点赞
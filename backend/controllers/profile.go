@@ -0,0 +1,335 @@
+package controllers
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"fmt"
+	"image"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 头像尺寸，第一个作为 User.Avatar
+var avatarSizes = []int{256, 128, 64}
+
+// 头像原图最大边长，解码后最多占用约 64MB 内存
+const maxAvatarDimension = 4096
+
+// 公开的用户资料（不含邮箱等私人信息）
+type PublicUser struct {
+	ID          uint      `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	Website     string    `json:"website"`
+	Avatar      string    `json:"avatar"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func toPublicUser(user models.User) PublicUser {
+	return PublicUser{
+		ID:          user.ID,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		Website:     user.Website,
+		Avatar:      user.Avatar,
+		CreatedAt:   user.CreatedAt,
+	}
+}
+
+// 更新个人资料
+func UpdateProfile(c *gin.Context) {
+	userID := c.GetUint("userID")
+	var user models.User
+	if err := models.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	// 字段为nil时保持不变
+	var profileData struct {
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		Email       *string `json:"email"`
+		Website     *string `json:"website"`
+	}
+
+	if err := c.ShouldBindJSON(&profileData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	updates := make(map[string]interface{})
+
+	if profileData.DisplayName != nil {
+		name := strings.TrimSpace(*profileData.DisplayName)
+		if utf8.RuneCountInString(name) > 50 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "显示名称不能超过50个字符"})
+			return
+		}
+		updates["display_name"] = name
+	}
+
+	if profileData.Bio != nil {
+		bio := strings.TrimSpace(*profileData.Bio)
+		if utf8.RuneCountInString(bio) > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "个人简介不能超过500个字符"})
+			return
+		}
+		updates["bio"] = bio
+	}
+
+	if profileData.Website != nil {
+		website := strings.TrimSpace(*profileData.Website)
+		if website != "" {
+			u, err := url.Parse(website)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "网站地址必须是有效的http(s)链接"})
+				return
+			}
+		}
+		updates["website"] = website
+	}
+
+	// 邮箱变更需要重新验证，验证通过前保留原邮箱
+	var pendingEmail, emailToken string
+	if profileData.Email != nil {
+		email := strings.TrimSpace(*profileData.Email)
+		if email != user.Email {
+			// 只接受纯地址，不接受 "Bob <bob@x.com>" 这样带名字的格式
+			addr, err := mail.ParseAddress(email)
+			if err != nil || addr.Address != email {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的邮箱地址"})
+				return
+			}
+
+			token, err := utils.RandomToken(32)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "生成验证令牌失败"})
+				return
+			}
+			expiresAt := time.Now().Add(24 * time.Hour)
+			updates["pending_email"] = email
+			updates["email_token"] = token
+			updates["email_token_expires_at"] = &expiresAt
+			pendingEmail, emailToken = email, token
+		}
+	}
+
+	// 先发送验证邮件，发送失败时不保存任何修改
+	if pendingEmail != "" {
+		link := fmt.Sprintf("%s/verify-email?token=%s", config.AppConfig.SiteURL, emailToken)
+		body := fmt.Sprintf("你好 %s，\n\n请在24小时内打开以下链接验证你的新邮箱：\n%s\n", user.Username, link)
+		if err := utils.SendMail(pendingEmail, "验证你的邮箱", body); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "发送验证邮件失败: " + err.Error()})
+			return
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := models.DB.Model(&user).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新个人资料失败"})
+			return
+		}
+	}
+
+	models.DB.First(&user, user.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":                        user,
+		"email_verification_required": pendingEmail != "",
+	})
+}
+
+// 验证邮箱
+func VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少验证令牌"})
+		return
+	}
+
+	var user models.User
+	if err := models.DB.Where("email_token = ?", token).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "验证令牌无效"})
+		return
+	}
+
+	if user.EmailTokenExpiresAt == nil || time.Now().After(*user.EmailTokenExpiresAt) {
+		c.JSON(http.StatusGone, gin.H{"error": "验证令牌已过期"})
+		return
+	}
+
+	email := user.PendingEmail
+	updates := map[string]interface{}{
+		"email":                  email,
+		"email_verified":         true,
+		"pending_email":          "",
+		"email_token":            "",
+		"email_token_expires_at": nil,
+	}
+	if err := models.DB.Model(&user).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "验证邮箱失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "邮箱验证成功", "email": email})
+}
+
+// 上传头像，居中裁剪为正方形并生成多种尺寸
+func UploadAvatar(c *gin.Context) {
+	userID := c.GetUint("userID")
+	var user models.User
+	if err := models.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "获取文件失败"})
+		return
+	}
+
+	if fileHeader.Size > config.AppConfig.MaxUploadSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文件大小超过限制"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取文件失败"})
+		return
+	}
+	defer file.Close()
+
+	// 先检查尺寸再解码
+	imgConfig, _, err := image.DecodeConfig(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只支持JPEG、PNG、GIF图片"})
+		return
+	}
+	if imgConfig.Width > maxAvatarDimension || imgConfig.Height > maxAvatarDimension {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("图片尺寸过大，长宽不能超过%d像素", maxAvatarDimension)})
+		return
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取文件失败"})
+		return
+	}
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "解析图片失败"})
+		return
+	}
+
+	avatarDir := filepath.Join(config.AppConfig.UploadPath, "avatars")
+	if err := os.MkdirAll(avatarDir, 0755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建头像目录失败"})
+		return
+	}
+
+	square := utils.CropSquare(img)
+	stamp := time.Now().UnixNano()
+	urls := make(map[string]string)
+	for _, size := range avatarSizes {
+		filename := fmt.Sprintf("%d_%d_%d.jpg", user.ID, size, stamp)
+		if err := utils.SaveJPEG(utils.ResizeSquare(square, size), filepath.Join(avatarDir, filename)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "保存头像失败"})
+			return
+		}
+		urls[strconv.Itoa(size)] = "/uploads/avatars/" + filename
+	}
+
+	oldAvatar := user.Avatar
+	avatarURL := urls[strconv.Itoa(avatarSizes[0])]
+	if err := models.DB.Model(&user).Update("avatar", avatarURL).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新头像失败"})
+		return
+	}
+
+	// 删除旧头像文件
+	removeAvatarFiles(user.ID, oldAvatar)
+
+	c.JSON(http.StatusOK, gin.H{
+		"avatar":  avatarURL,
+		"avatars": urls,
+	})
+}
+
+// 头像URL对应的各尺寸文件路径，头像文件名为 用户ID_尺寸_时间戳.jpg，不是本站上传的头像时返回nil
+func avatarFiles(avatarURL string) []string {
+	name := strings.TrimPrefix(avatarURL, "/uploads/avatars/")
+	if config.AppConfig == nil || name == avatarURL || !strings.HasSuffix(name, ".jpg") || strings.ContainsAny(name, "/\\") {
+		return nil
+	}
+	parts := strings.Split(strings.TrimSuffix(name, ".jpg"), "_")
+	if len(parts) != 3 {
+		return nil
+	}
+
+	dir := filepath.Join(config.AppConfig.UploadPath, "avatars")
+	files := make([]string, 0, len(avatarSizes))
+	for _, size := range avatarSizes {
+		files = append(files, filepath.Join(dir, fmt.Sprintf("%s_%d_%s.jpg", parts[0], size, parts[2])))
+	}
+	return files
+}
+
+// 公开用户主页：资料、发表的文章和点赞的文章
+func GetUserProfile(c *gin.Context) {
+	username := c.Param("username")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	var user models.User
+	if err := models.DB.Where("username = ? AND deletion_requested_at IS NULL", username).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	authoredPosts := []models.Post{}
+	if err := models.DB.Preload("Tags").
+		Where("author_id = ? AND published = ?", user.ID, true).
+		Order("created_at DESC").Limit(limit).
+		Find(&authoredPosts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取文章失败"})
+		return
+	}
+
+	likedPosts := []models.Post{}
+	if err := models.DB.Preload("Tags").
+		Joins("JOIN post_likes ON post_likes.post_id = posts.id").
+		Where("post_likes.user_id = ? AND posts.published = ?", user.ID, true).
+		Order("post_likes.id DESC").Limit(limit).
+		Find(&likedPosts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取点赞文章失败"})
+		return
+	}
+
+	var authoredCount, likedCount int64
+	models.DB.Model(&models.Post{}).Where("author_id = ? AND published = ?", user.ID, true).Count(&authoredCount)
+	models.DB.Model(&models.PostLike{}).Where("user_id = ?", user.ID).Count(&likedCount)
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":           toPublicUser(user),
+		"authored_posts": authoredPosts,
+		"liked_posts":    likedPosts,
+		"authored_count": authoredCount,
+		"liked_count":    likedCount,
+	})
+}
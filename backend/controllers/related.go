@@ -0,0 +1,354 @@
+package controllers
+
+import (
+	"blog-backend/models"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 相关文章评分中标签和正文相似度的权重
+const (
+	relatedTagWeight  = 0.6
+	relatedTextWeight = 0.4
+)
+
+const (
+	maxRelatedPosts   = 20        // 每篇文章缓存的相关文章数，也是接口 limit 的上限
+	relatedTermsLimit = 64        // 每篇文章保留权重最高的词数
+	relatedTitleBoost = 3         // 标题中的词按出现多次计算
+	relatedIndexTTL   = time.Hour // 没有显式失效时索引的最长有效期
+)
+
+// 计算相似度时忽略的常见词
+var relatedStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "but": true, "not": true, "you": true,
+	"all": true, "can": true, "was": true, "one": true, "our": true, "out": true, "has": true,
+	"this": true, "that": true, "with": true, "from": true, "have": true, "they": true,
+	"will": true, "your": true, "what": true, "when": true, "which": true, "their": true,
+	"there": true, "about": true, "into": true, "than": true, "then": true, "them": true,
+	"these": true, "some": true, "would": true, "could": true, "should": true, "also": true,
+	"how": true, "its": true, "use": true, "using": true, "is": true, "in": true, "of": true,
+	"to": true, "it": true, "on": true, "as": true, "be": true, "by": true, "or": true,
+	"an": true, "at": true, "we": true, "if": true, "do": true, "so": true, "no": true,
+	"http": true, "https": true, "www": true, "com": true, "html": true, "png": true,
+	"jpg": true, "jpeg": true, "gif": true, "img": true, "src": true, "href": true,
+}
+
+// 相关文章的简要信息，不包含正文
+type RelatedPost struct {
+	ID         uint         `json:"id"`
+	Title      string       `json:"title"`
+	Slug       string       `json:"slug"`
+	Summary    string       `json:"summary"`
+	CoverImage string       `json:"cover_image"`
+	CreatedAt  time.Time    `json:"created_at"`
+	Tags       []models.Tag `json:"tags"`
+	Score      float64      `json:"score"`
+}
+
+// 索引中的一篇已发布文章
+type relatedDoc struct {
+	post   RelatedPost
+	tags   map[uint]bool
+	vector map[string]float64 // 归一化的 TF-IDF 向量
+}
+
+// 所有已发布文章的标签和词向量，以及按文章缓存的计算结果
+// docs 和 tagIDF 建好后只读，results 由 mu 保护
+type relatedIndex struct {
+	docs    map[uint]*relatedDoc
+	tagIDF  map[uint]float64
+	builtAt time.Time
+
+	mu      sync.Mutex
+	results map[uint][]RelatedPost
+}
+
+// 锁只保护指针的读取和替换，重建索引在锁外进行
+var related struct {
+	sync.Mutex
+	index      *relatedIndex
+	generation int           // 每次失效加1，重建期间失效时丢弃建好的旧索引
+	building   chan struct{} // 正在重建时非nil，重建结束后关闭
+}
+
+// 文章或标签变化后清除相关文章缓存，下次请求时重建
+func invalidateRelatedPosts() {
+	related.Lock()
+	related.index = nil
+	related.generation++
+	related.Unlock()
+}
+
+// 获取当前索引，过期或失效时重建；同一时间只有一个请求重建，其他请求等待结果
+func currentRelatedIndex() (*relatedIndex, error) {
+	for {
+		related.Lock()
+		if index := related.index; index != nil && time.Since(index.builtAt) <= relatedIndexTTL {
+			related.Unlock()
+			return index, nil
+		}
+		if building := related.building; building != nil {
+			related.Unlock()
+			<-building
+			continue
+		}
+		done := make(chan struct{})
+		related.building = done
+		generation := related.generation
+		related.Unlock()
+
+		index, err := buildRelatedIndex()
+
+		related.Lock()
+		related.building = nil
+		if err == nil && generation == related.generation {
+			related.index = index
+		}
+		related.Unlock()
+		close(done)
+		return index, err
+	}
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// 分词：英文等按单词切分并转为小写，中日韩文字没有空格分隔，按相邻两字切分
+func relatedTokens(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) >= 2 {
+			w := strings.ToLower(string(word))
+			if !relatedStopWords[w] && strings.TrimFunc(w, unicode.IsDigit) != "" {
+				tokens = append(tokens, w)
+			}
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			tokens = append(tokens, string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			tokens = append(tokens, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+// 读取所有已发布文章并计算标签IDF和正文TF-IDF向量
+func buildRelatedIndex() (*relatedIndex, error) {
+	var posts []models.Post
+	if err := models.DB.Preload("Tags").Where("published = ?", true).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+
+	index := &relatedIndex{
+		docs:    make(map[uint]*relatedDoc, len(posts)),
+		tagIDF:  make(map[uint]float64),
+		results: make(map[uint][]RelatedPost),
+		builtAt: time.Now(),
+	}
+
+	tagDF := make(map[uint]int)
+	termDF := make(map[string]int)
+	termCounts := make(map[uint]map[string]int, len(posts))
+	for _, post := range posts {
+		doc := &relatedDoc{
+			post: RelatedPost{
+				ID: post.ID, Title: post.Title, Slug: post.Slug, Summary: post.Summary,
+				CoverImage: post.CoverImage, CreatedAt: post.CreatedAt, Tags: post.Tags,
+			},
+			tags: make(map[uint]bool, len(post.Tags)),
+		}
+		if doc.post.Tags == nil {
+			doc.post.Tags = []models.Tag{}
+		}
+		for _, tag := range post.Tags {
+			doc.tags[tag.ID] = true
+			tagDF[tag.ID]++
+		}
+
+		counts := make(map[string]int)
+		for _, token := range relatedTokens(post.Title) {
+			counts[token] += relatedTitleBoost
+		}
+		for _, token := range relatedTokens(post.Content) {
+			counts[token]++
+		}
+		for term := range counts {
+			termDF[term]++
+		}
+		termCounts[post.ID] = counts
+		index.docs[post.ID] = doc
+	}
+
+	// 标签越少见，共同拥有它的两篇文章越相关
+	n := float64(len(posts))
+	for tagID, df := range tagDF {
+		index.tagIDF[tagID] = math.Log(1 + n/float64(df))
+	}
+
+	for postID, counts := range termCounts {
+		type weighted struct {
+			term   string
+			weight float64
+		}
+		terms := make([]weighted, 0, len(counts))
+		for term, count := range counts {
+			// 只出现在一篇文章中的词对相似度没有帮助
+			if termDF[term] < 2 {
+				continue
+			}
+			tf := 1 + math.Log(float64(count))
+			idf := math.Log(n / float64(termDF[term]))
+			if idf > 0 {
+				terms = append(terms, weighted{term, tf * idf})
+			}
+		}
+		sort.Slice(terms, func(i, j int) bool { return terms[i].weight > terms[j].weight })
+		if len(terms) > relatedTermsLimit {
+			terms = terms[:relatedTermsLimit]
+		}
+
+		var norm float64
+		for _, t := range terms {
+			norm += t.weight * t.weight
+		}
+		norm = math.Sqrt(norm)
+		vector := make(map[string]float64, len(terms))
+		for _, t := range terms {
+			vector[t.term] = t.weight / norm
+		}
+		index.docs[postID].vector = vector
+	}
+	return index, nil
+}
+
+// 计算与 source 最相关的文章
+// 标签相似度为共同标签的IDF之和占 source 所有标签IDF之和的比例，正文相似度为TF-IDF向量的余弦相似度
+func (index *relatedIndex) rank(source *relatedDoc) []RelatedPost {
+	var sourceTagWeight float64
+	for tagID := range source.tags {
+		sourceTagWeight += index.tagIDF[tagID]
+	}
+
+	var ranked []RelatedPost
+	for id, doc := range index.docs {
+		if id == source.post.ID {
+			continue
+		}
+
+		var tagScore float64
+		if sourceTagWeight > 0 {
+			for tagID := range source.tags {
+				if doc.tags[tagID] {
+					tagScore += index.tagIDF[tagID]
+				}
+			}
+			tagScore /= sourceTagWeight
+		}
+
+		var textScore float64
+		for term, weight := range source.vector {
+			textScore += weight * doc.vector[term]
+		}
+
+		score := relatedTagWeight*tagScore + relatedTextWeight*textScore
+		if score <= 0 {
+			continue
+		}
+		post := doc.post
+		post.Score = math.Round(score*10000) / 10000
+		ranked = append(ranked, post)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].ID > ranked[j].ID
+	})
+	if len(ranked) > maxRelatedPosts {
+		ranked = ranked[:maxRelatedPosts]
+	}
+	return ranked
+}
+
+// 获取相关文章
+func GetRelatedPosts(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if limit < 1 || limit > maxRelatedPosts {
+		limit = 5
+	}
+
+	var post models.Post
+	if err := models.DB.Preload("Tags").First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+
+	index, err := currentRelatedIndex()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取相关文章失败"})
+		return
+	}
+
+	index.mu.Lock()
+	results, ok := index.results[post.ID]
+	index.mu.Unlock()
+	if !ok {
+		// 未发布的文章不在索引中，只按标签计算
+		source, published := index.docs[post.ID]
+		if !published {
+			source = &relatedDoc{post: RelatedPost{ID: post.ID}, tags: make(map[uint]bool)}
+			for _, tag := range post.Tags {
+				source.tags[tag.ID] = true
+			}
+		}
+		results = index.rank(source)
+		if published {
+			index.mu.Lock()
+			index.results[post.ID] = results
+			index.mu.Unlock()
+		}
+	}
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	if results == nil {
+		results = []RelatedPost{}
+	}
+	c.JSON(http.StatusOK, gin.H{"posts": results})
+}
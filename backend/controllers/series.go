@@ -0,0 +1,303 @@
+package controllers
+
+import (
+	"blog-backend/models"
+	"blog-backend/utils"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 系列中的一篇文章，不包含正文
+type SeriesPart struct {
+	ID         uint      `json:"id"`
+	Title      string    `json:"title"`
+	Slug       string    `json:"slug"`
+	Summary    string    `json:"summary"`
+	CoverImage string    `json:"cover_image"`
+	Published  bool      `json:"published"`
+	CreatedAt  time.Time `json:"created_at"`
+	Position   int       `json:"position"`
+}
+
+// 按顺序列出系列中的文章，includeDrafts 为 false 时只列出已发布的文章和 exceptID 指定的文章
+func seriesParts(seriesID uint, includeDrafts bool, exceptID uint) ([]SeriesPart, error) {
+	query := models.DB.Table("series_posts AS sp").
+		Select("p.id, p.title, p.slug, p.summary, p.cover_image, p.published, p.created_at, sp.position").
+		Joins("JOIN posts p ON p.id = sp.post_id").
+		Where("sp.series_id = ?", seriesID)
+	if !includeDrafts {
+		query = query.Where("p.published = ? OR p.id = ?", true, exceptID)
+	}
+
+	parts := []SeriesPart{}
+	if err := query.Order("sp.position, p.id").Scan(&parts).Error; err != nil {
+		return nil, err
+	}
+	// 删除文章后位置可能不连续，返回时重新编号
+	for i := range parts {
+		parts[i].Position = i + 1
+	}
+	return parts, nil
+}
+
+// 查询文章所属的系列，以及在系列已发布文章中的位置和前后篇
+func postSeriesInfo(post *models.Post) (*models.PostSeriesInfo, error) {
+	var membership models.SeriesPost
+	err := models.DB.Where("post_id = ?", post.ID).Limit(1).Find(&membership).Error
+	if err != nil || membership.SeriesID == 0 {
+		return nil, err
+	}
+
+	var series models.Series
+	if err := models.DB.First(&series, membership.SeriesID).Error; err != nil {
+		return nil, err
+	}
+	parts, err := seriesParts(series.ID, false, post.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &models.PostSeriesInfo{ID: series.ID, Title: series.Title, Slug: series.Slug, Total: len(parts)}
+	for i, part := range parts {
+		if part.ID != post.ID {
+			continue
+		}
+		info.Position = part.Position
+		if i > 0 {
+			info.Previous = &models.SeriesPartRef{ID: parts[i-1].ID, Title: parts[i-1].Title, Slug: parts[i-1].Slug}
+		}
+		if i+1 < len(parts) {
+			info.Next = &models.SeriesPartRef{ID: parts[i+1].ID, Title: parts[i+1].Title, Slug: parts[i+1].Slug}
+		}
+	}
+	return info, nil
+}
+
+// slug 是否已被其他系列使用
+func seriesSlugTaken(tx *gorm.DB, slug string, exceptID uint) bool {
+	var count int64
+	tx.Model(&models.Series{}).Where("slug = ? AND id <> ?", slug, exceptID).Count(&count)
+	return count > 0
+}
+
+// 检查要加入系列的文章：不能重复，必须存在，且不属于其他系列
+func validateSeriesPosts(seriesID uint, postIDs []uint) (int, string) {
+	seen := make(map[uint]bool, len(postIDs))
+	for _, id := range postIDs {
+		if seen[id] {
+			return http.StatusBadRequest, fmt.Sprintf("文章 %d 重复", id)
+		}
+		seen[id] = true
+	}
+	if len(postIDs) == 0 {
+		return 0, ""
+	}
+
+	var count int64
+	models.DB.Model(&models.Post{}).Where("id IN ?", postIDs).Count(&count)
+	if count != int64(len(postIDs)) {
+		return http.StatusBadRequest, "部分文章不存在"
+	}
+
+	var taken []uint
+	models.DB.Model(&models.SeriesPost{}).Where("post_id IN ? AND series_id <> ?", postIDs, seriesID).
+		Pluck("post_id", &taken)
+	if len(taken) > 0 {
+		return http.StatusConflict, fmt.Sprintf("文章 %v 已属于其他系列", taken)
+	}
+	return 0, ""
+}
+
+// 按给定顺序替换系列中的文章
+func replaceSeriesPosts(tx *gorm.DB, seriesID uint, postIDs []uint) error {
+	if err := tx.Where("series_id = ?", seriesID).Delete(&models.SeriesPost{}).Error; err != nil {
+		return err
+	}
+	if len(postIDs) == 0 {
+		return nil
+	}
+	members := make([]models.SeriesPost, len(postIDs))
+	for i, postID := range postIDs {
+		members[i] = models.SeriesPost{SeriesID: seriesID, PostID: postID, Position: i + 1}
+	}
+	return tx.Create(&members).Error
+}
+
+// 获取所有系列及其已发布文章数
+func GetSeriesList(c *gin.Context) {
+	type seriesSummary struct {
+		models.Series
+		PostCount int64 `json:"post_count"`
+	}
+	list := []seriesSummary{}
+	if err := models.DB.Table("series AS s").
+		Select("s.*, (SELECT COUNT(*) FROM series_posts sp JOIN posts p ON p.id = sp.post_id "+
+			"WHERE sp.series_id = s.id AND p.published = ?) AS post_count", true).
+		Order("s.created_at DESC").Scan(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取系列失败"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// 获取系列及其按顺序排列的已发布文章
+func GetSeries(c *gin.Context) {
+	var series models.Series
+	if err := models.DB.Where("slug = ?", c.Param("slug")).First(&series).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "系列不存在"})
+		return
+	}
+
+	parts, err := seriesParts(series.ID, false, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取系列文章失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": series, "posts": parts})
+}
+
+// 系列的请求数据，post_ids 按顺序列出系列中的文章，更新时省略表示不修改
+type seriesRequest struct {
+	Title       *string `json:"title"`
+	Slug        *string `json:"slug"` // 为空时根据标题生成
+	Description *string `json:"description"`
+	PostIDs     *[]uint `json:"post_ids"`
+}
+
+// 检查并应用请求中的标题、slug和描述，失败时已写入响应
+func applySeriesRequest(c *gin.Context, series *models.Series, req *seriesRequest) bool {
+	if req.Title != nil {
+		series.Title = strings.TrimSpace(*req.Title)
+	}
+	if series.Title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "系列标题不能为空"})
+		return false
+	}
+	if req.Description != nil {
+		series.Description = *req.Description
+	}
+
+	if req.Slug != nil && *req.Slug != "" {
+		if !validPostSlug(*req.Slug) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "slug只能包含小写字母、数字和连字符"})
+			return false
+		}
+		if seriesSlugTaken(models.DB, *req.Slug, series.ID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "slug已被其他系列使用"})
+			return false
+		}
+		series.Slug = *req.Slug
+	} else if series.Slug == "" || req.Slug != nil {
+		// 根据标题生成，被占用时依次追加 -2、-3…
+		base := utils.Slugify(series.Title)
+		if base == "" {
+			base = "series"
+		}
+		series.Slug = base
+		for i := 2; seriesSlugTaken(models.DB, series.Slug, series.ID); i++ {
+			series.Slug = fmt.Sprintf("%s-%d", base, i)
+		}
+	}
+
+	if req.PostIDs != nil {
+		if status, msg := validateSeriesPosts(series.ID, *req.PostIDs); status != 0 {
+			c.JSON(status, gin.H{"error": msg})
+			return false
+		}
+	}
+	return true
+}
+
+// 创建系列
+func CreateSeries(c *gin.Context) {
+	var req seriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	var series models.Series
+	if !applySeriesRequest(c, &series, &req) {
+		return
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&series).Error; err != nil {
+			return err
+		}
+		if req.PostIDs != nil {
+			return replaceSeriesPosts(tx, series.ID, *req.PostIDs)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建系列失败"})
+		return
+	}
+
+	parts, _ := seriesParts(series.ID, true, 0)
+	c.JSON(http.StatusCreated, gin.H{"series": series, "posts": parts})
+}
+
+// 更新系列，post_ids 按新的顺序替换系列中的文章
+func UpdateSeries(c *gin.Context) {
+	var series models.Series
+	if err := models.DB.First(&series, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "系列不存在"})
+		return
+	}
+
+	var req seriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	if !applySeriesRequest(c, &series, &req) {
+		return
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&series).Error; err != nil {
+			return err
+		}
+		if req.PostIDs != nil {
+			return replaceSeriesPosts(tx, series.ID, *req.PostIDs)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新系列失败"})
+		return
+	}
+
+	parts, _ := seriesParts(series.ID, true, 0)
+	c.JSON(http.StatusOK, gin.H{"series": series, "posts": parts})
+}
+
+// 删除系列，系列中的文章保留
+func DeleteSeries(c *gin.Context) {
+	var series models.Series
+	if err := models.DB.First(&series, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "系列不存在"})
+		return
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := replaceSeriesPosts(tx, series.ID, nil); err != nil {
+			return err
+		}
+		return tx.Delete(&series).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除系列失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "系列删除成功"})
+}
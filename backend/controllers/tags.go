@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // 获取所有标签
@@ -69,6 +70,8 @@ func UpdateTag(c *gin.Context) {
 		return
 	}
 
+	invalidateRelatedPosts()
+
 	c.JSON(http.StatusOK, tag)
 }
 
@@ -82,10 +85,19 @@ func DeleteTag(c *gin.Context) {
 		return
 	}
 
-	if err := models.DB.Delete(&tag).Error; err != nil {
+	// 同时删除文章与该标签的关联
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM post_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除标签失败"})
 		return
 	}
 
+	invalidateRelatedPosts()
+
 	c.JSON(http.StatusOK, gin.H{"message": "标签删除成功"})
 }
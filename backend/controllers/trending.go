@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 上次计算热度的时间
+var trendingUpdated struct {
+	sync.Mutex
+	at time.Time
+}
+
+// 按最近 windowDays 天的每日统计计算文章热度
+// 每天的浏览量和点赞按该天距现在的时间衰减 (Hacker News 的 gravity 公式)：
+//
+//	score = Σ (views + 点赞权重 × (likes - unlikes)) / (小时数 + 2) ^ gravity
+//
+// 小时数从该天的中午算起，当天则从已过去时间的一半算起；没有近期访问的文章热度为0
+func computeTrendingScores(db *gorm.DB, windowDays int, now time.Time) (map[uint]float64, error) {
+	from := now.AddDate(0, 0, -(windowDays - 1)).Format(models.StatDayFormat)
+
+	var stats []models.PostDailyStat
+	if err := db.Where("day >= ?", from).Find(&stats).Error; err != nil {
+		return nil, err
+	}
+
+	gravity := config.AppConfig.TrendingGravity
+	likeWeight := config.AppConfig.TrendingLikeWeight
+	scores := make(map[uint]float64)
+	for _, stat := range stats {
+		points := float64(stat.Views) + likeWeight*float64(stat.Likes-stat.Unlikes)
+		if points <= 0 {
+			continue
+		}
+		dayStart, err := time.ParseInLocation(models.StatDayFormat, stat.Day, time.Local)
+		if err != nil {
+			continue
+		}
+		mid := dayStart.Add(12 * time.Hour)
+		if mid.After(now) {
+			mid = dayStart.Add(now.Sub(dayStart) / 2)
+		}
+		age := math.Max(now.Sub(mid).Hours(), 0)
+		scores[stat.PostID] += points / math.Pow(age+2, gravity)
+	}
+	return scores, nil
+}
+
+// 重新计算所有文章的热度分数并写入 trending_score 列，返回有热度的文章数
+func RecomputeTrendingScores() (int, error) {
+	// 先写入缓冲的浏览量，热度包含最新数据
+	FlushViews()
+
+	now := time.Now()
+	scores, err := computeTrendingScores(models.DB, int(config.AppConfig.TrendingWindowDays), now)
+	if err != nil {
+		return 0, err
+	}
+
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Post{}).Where("trending_score <> 0").
+			UpdateColumn("trending_score", 0).Error; err != nil {
+			return err
+		}
+		for postID, score := range scores {
+			if err := tx.Model(&models.Post{}).Where("id = ?", postID).
+				UpdateColumn("trending_score", score).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	trendingUpdated.Lock()
+	trendingUpdated.at = now
+	trendingUpdated.Unlock()
+	return len(scores), nil
+}
+
+// 启动后台任务，按配置定期重新计算热度
+func StartTrendingUpdater() {
+	interval := time.Duration(config.AppConfig.TrendingInterval) * time.Minute
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := RecomputeTrendingScores(); err != nil {
+				log.Printf("计算文章热度失败: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// 获取热门文章
+// window 为统计天数，默认使用 TRENDING_WINDOW_DAYS 并读取定期计算的热度；
+// 指定其他天数时按该时间范围即时计算
+func GetTrendingPosts(c *gin.Context) {
+	defaultWindow := int(config.AppConfig.TrendingWindowDays)
+	window, err := strconv.Atoi(c.DefaultQuery("window", strconv.Itoa(defaultWindow)))
+	if err != nil || window < 1 || window > config.MaxTrendingWindowDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("window 应为1到%d之间的天数", config.MaxTrendingWindowDays)})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	posts := []models.Post{}
+	updatedAt := time.Now()
+	if window == defaultWindow {
+		if err := models.DB.Preload("Tags").
+			Where("published = ? AND trending_score > 0", true).
+			Order("trending_score DESC, created_at DESC").Limit(limit).
+			Find(&posts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取热门文章失败"})
+			return
+		}
+		trendingUpdated.Lock()
+		updatedAt = trendingUpdated.at
+		trendingUpdated.Unlock()
+	} else {
+		FlushViews()
+		scores, err := computeTrendingScores(models.DB, window, updatedAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取热门文章失败"})
+			return
+		}
+		ids := make([]uint, 0, len(scores))
+		for id := range scores {
+			ids = append(ids, id)
+		}
+
+		if len(ids) > 0 {
+			if err := models.DB.Preload("Tags").Where("published = ? AND id IN ?", true, ids).
+				Find(&posts).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "获取热门文章失败"})
+				return
+			}
+		}
+		for i := range posts {
+			posts[i].TrendingScore = scores[posts[i].ID]
+		}
+		sort.SliceStable(posts, func(i, j int) bool {
+			if posts[i].TrendingScore != posts[j].TrendingScore {
+				return posts[i].TrendingScore > posts[j].TrendingScore
+			}
+			return posts[i].CreatedAt.After(posts[j].CreatedAt)
+		})
+		if len(posts) > limit {
+			posts = posts[:limit]
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts":      posts,
+		"window":     window,
+		"updated_at": updatedAt,
+	})
+}
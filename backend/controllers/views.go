@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 不计入浏览量的 User-Agent 关键字 (小写)，覆盖常见搜索引擎、社交平台预览和命令行工具
+var botUserAgents = []string{
+	"bot", "crawl", "spider", "slurp", "mediapartners", "facebookexternalhit",
+	"embedly", "preview", "headless", "lighthouse", "pingdom", "uptime", "monitor",
+	"curl", "wget", "python-requests", "python-urllib", "go-http-client", "java/",
+	"okhttp", "httpclient", "axios", "node-fetch", "scrapy", "feedfetcher",
+}
+
+// 去重表的最大条目数，超过后新访客的浏览不再计数，防止内存被刷满
+const maxViewFingerprints = 100000
+
+// 每日统计的缓冲键
+type dailyStatKey struct {
+	postID uint
+	day    string
+}
+
+type referrerStatKey struct {
+	postID uint
+	day    string
+	domain string
+}
+
+// 内存中的浏览量缓冲区，定期用 view_count = view_count + n 原子写入数据库，
+// 同时累计每日浏览量、独立访客和来源域名
+type viewCounter struct {
+	mu          sync.Mutex
+	pending     map[uint]int64 // 文章ID -> 尚未写入的浏览量
+	daily       map[dailyStatKey]*models.PostDailyStat
+	referrers   map[referrerStatKey]int64
+	seen        map[string]time.Time // 访客指纹:文章ID -> 去重窗口结束时间
+	visitors    map[string]bool      // 当天访问过的 访客指纹:文章ID，每天清空
+	visitorsDay string
+	window      time.Duration
+	ignore      []string
+}
+
+var views = &viewCounter{
+	pending:   make(map[uint]int64),
+	daily:     make(map[dailyStatKey]*models.PostDailyStat),
+	referrers: make(map[referrerStatKey]int64),
+	seen:      make(map[string]time.Time),
+	visitors:  make(map[string]bool),
+	window:    30 * time.Minute,
+	ignore:    botUserAgents,
+}
+
+// 判断是否为爬虫或自动化工具的请求
+func (v *viewCounter) isBot(userAgent string) bool {
+	ua := strings.ToLower(userAgent)
+	for _, keyword := range v.ignore {
+		if strings.Contains(ua, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// 访客指纹：IP 和 User-Agent 的哈希，不在内存中保存原始IP
+func viewFingerprint(c *gin.Context) string {
+	sum := sha256.Sum256([]byte(c.ClientIP() + "\x00" + c.Request.UserAgent()))
+	return hex.EncodeToString(sum[:12])
+}
+
+// 比较域名时忽略 www. 前缀
+func normalizeDomain(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
+
+// 外部来源域名，站内跳转和没有来源时返回空
+// 前端是单页应用，请求接口时的 Referer 是站内页面，所以优先使用前端传来的 ref 参数 (document.referrer)
+func referrerDomain(c *gin.Context) string {
+	raw := c.Query("ref")
+	if raw == "" {
+		raw = c.Request.Referer()
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+
+	domain := normalizeDomain(u.Hostname())
+	if len(domain) > 255 {
+		return ""
+	}
+	if site, err := url.Parse(config.AppConfig.SiteURL); err == nil && normalizeDomain(site.Hostname()) == domain {
+		return ""
+	}
+	host := c.Request.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if normalizeDomain(host) == domain {
+		return ""
+	}
+	return domain
+}
+
+func (v *viewCounter) dailyLocked(postID uint, day string) *models.PostDailyStat {
+	key := dailyStatKey{postID, day}
+	stat, ok := v.daily[key]
+	if !ok {
+		stat = &models.PostDailyStat{PostID: postID, Day: day}
+		v.daily[key] = stat
+	}
+	return stat
+}
+
+// 记录一次浏览，返回该文章尚未写入数据库的浏览量
+func (v *viewCounter) record(c *gin.Context, postID uint) int64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.isBot(c.Request.UserAgent()) {
+		return v.pending[postID]
+	}
+
+	now := time.Now()
+	day := now.Format(models.StatDayFormat)
+	if day != v.visitorsDay {
+		v.visitors = make(map[string]bool)
+		v.visitorsDay = day
+	}
+
+	key := viewFingerprint(c) + ":" + strconv.FormatUint(uint64(postID), 10)
+	if !v.visitors[key] && len(v.visitors) < maxViewFingerprints {
+		v.visitors[key] = true
+		v.dailyLocked(postID, day).Visitors++
+	}
+
+	if until, ok := v.seen[key]; ok && now.Before(until) {
+		return v.pending[postID]
+	}
+	if len(v.seen) >= maxViewFingerprints {
+		v.pruneLocked(now)
+		if len(v.seen) >= maxViewFingerprints {
+			return v.pending[postID]
+		}
+	}
+
+	v.seen[key] = now.Add(v.window)
+	v.pending[postID]++
+	v.dailyLocked(postID, day).Views++
+	if domain := referrerDomain(c); domain != "" {
+		v.referrers[referrerStatKey{postID, day, domain}]++
+	}
+	return v.pending[postID]
+}
+
+// 删除已过去重窗口的指纹
+func (v *viewCounter) pruneLocked(now time.Time) {
+	for key, until := range v.seen {
+		if !now.Before(until) {
+			delete(v.seen, key)
+		}
+	}
+}
+
+// 把每日统计累加到数据库中，当天的记录不存在时创建
+func addPostDailyStat(tx *gorm.DB, stat *models.PostDailyStat) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "post_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"views":    gorm.Expr("post_daily_stats.views + ?", stat.Views),
+			"visitors": gorm.Expr("post_daily_stats.visitors + ?", stat.Visitors),
+			"likes":    gorm.Expr("post_daily_stats.likes + ?", stat.Likes),
+			"unlikes":  gorm.Expr("post_daily_stats.unlikes + ?", stat.Unlikes),
+		}),
+	}).Create(stat).Error
+}
+
+func addPostReferrerStat(tx *gorm.DB, stat *models.PostReferrerStat) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "post_id"}, {Name: "day"}, {Name: "domain"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("post_referrer_stats.views + ?", stat.Views)}),
+	}).Create(stat).Error
+}
+
+// 把缓冲的浏览量和统计写入数据库，失败时放回缓冲区等待下次写入
+func (v *viewCounter) flush() error {
+	v.mu.Lock()
+	pending, daily, referrers := v.pending, v.daily, v.referrers
+	v.pending = make(map[uint]int64)
+	v.daily = make(map[dailyStatKey]*models.PostDailyStat)
+	v.referrers = make(map[referrerStatKey]int64)
+	v.pruneLocked(time.Now())
+	v.mu.Unlock()
+
+	if len(pending) == 0 && len(daily) == 0 {
+		return nil
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		for postID, n := range pending {
+			if err := tx.Model(&models.Post{}).Where("id = ?", postID).
+				UpdateColumn("view_count", gorm.Expr("view_count + ?", n)).Error; err != nil {
+				return err
+			}
+		}
+		for _, stat := range daily {
+			if err := addPostDailyStat(tx, stat); err != nil {
+				return err
+			}
+		}
+		for key, n := range referrers {
+			stat := &models.PostReferrerStat{PostID: key.postID, Day: key.day, Domain: key.domain, Views: n}
+			if err := addPostReferrerStat(tx, stat); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		v.mu.Lock()
+		for postID, n := range pending {
+			v.pending[postID] += n
+		}
+		for key, stat := range daily {
+			merged := v.dailyLocked(key.postID, key.day)
+			merged.Views += stat.Views
+			merged.Visitors += stat.Visitors
+		}
+		for key, n := range referrers {
+			v.referrers[key] += n
+		}
+		v.mu.Unlock()
+	}
+	return err
+}
+
+// 记录当天的点赞或取消点赞，与点赞记录在同一个事务中写入
+func recordPostLikeStat(tx *gorm.DB, postID uint, liked bool) error {
+	stat := &models.PostDailyStat{PostID: postID, Day: time.Now().Format(models.StatDayFormat)}
+	if liked {
+		stat.Likes = 1
+	} else {
+		stat.Unlikes = 1
+	}
+	return addPostDailyStat(tx, stat)
+}
+
+// 立即写入缓冲的浏览量，服务器退出前调用
+func FlushViews() {
+	if err := views.flush(); err != nil {
+		log.Printf("写入浏览量失败: %v", err)
+	}
+}
+
+// 启动后台任务，按配置定期写入缓冲的浏览量
+func StartViewCounter() {
+	flushInterval := time.Duration(config.AppConfig.ViewFlushInterval) * time.Second
+	if flushInterval <= 0 {
+		flushInterval = 10 * time.Second
+	}
+
+	views.mu.Lock()
+	views.window = time.Duration(config.AppConfig.ViewDedupeWindow) * time.Minute
+	views.ignore = append([]string(nil), botUserAgents...)
+	for _, keyword := range strings.Split(config.AppConfig.ViewIgnoreUserAgents, ",") {
+		if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+			views.ignore = append(views.ignore, keyword)
+		}
+	}
+	views.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			FlushViews()
+		}
+	}()
+}
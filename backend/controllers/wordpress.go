@@ -0,0 +1,405 @@
+package controllers
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WordPress 导出文件 (WXR) 的结构
+// 字段标签不带命名空间，兼容 WXR 1.0 ~ 1.2 不同的 wp 命名空间地址
+type wxrDocument struct {
+	Channel wxrChannel `xml:"channel"`
+}
+
+type wxrChannel struct {
+	Title      string        `xml:"title"`
+	Link       string        `xml:"link"`
+	Authors    []wxrAuthor   `xml:"author"`
+	Categories []wxrCategory `xml:"category"`
+	Tags       []wxrTag      `xml:"tag"`
+	Items      []wxrItem     `xml:"item"`
+}
+
+type wxrAuthor struct {
+	Login       string `xml:"author_login"`
+	Email       string `xml:"author_email"`
+	DisplayName string `xml:"author_display_name"`
+}
+
+type wxrCategory struct {
+	Name string `xml:"cat_name"`
+	Slug string `xml:"category_nicename"`
+}
+
+type wxrTag struct {
+	Name string `xml:"tag_name"`
+	Slug string `xml:"tag_slug"`
+}
+
+type wxrItem struct {
+	Title         string            `xml:"title"`
+	Creator       string            `xml:"creator"`
+	Encoded       []wxrEncoded      `xml:"encoded"` // content:encoded 和 excerpt:encoded
+	PostID        uint              `xml:"post_id"`
+	PostDate      string            `xml:"post_date"`
+	PostDateGMT   string            `xml:"post_date_gmt"`
+	Modified      string            `xml:"post_modified"`
+	ModifiedGMT   string            `xml:"post_modified_gmt"`
+	Status        string            `xml:"status"`
+	PostType      string            `xml:"post_type"`
+	PostName      string            `xml:"post_name"`
+	AttachmentURL string            `xml:"attachment_url"`
+	Categories    []wxrItemCategory `xml:"category"`
+	Meta          []wxrMeta         `xml:"postmeta"`
+}
+
+type wxrEncoded struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+type wxrItemCategory struct {
+	Domain   string `xml:"domain,attr"` // category 或 post_tag
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+type wxrMeta struct {
+	Key   string `xml:"meta_key"`
+	Value string `xml:"meta_value"`
+}
+
+// 按命名空间区分正文和摘要
+func (item *wxrItem) encoded(kind string) string {
+	for _, e := range item.Encoded {
+		if strings.Contains(e.XMLName.Space, "/"+kind) {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+func (item *wxrItem) meta(key string) string {
+	for _, m := range item.Meta {
+		if m.Key == key {
+			return m.Value
+		}
+	}
+	return ""
+}
+
+// post_name 中的非ASCII字符是URL编码的
+func wxrSlug(name string) string {
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		return unescaped
+	}
+	return name
+}
+
+// 发布时间优先使用GMT时间，草稿的GMT时间为 0000-00-00
+func parseWXRTime(gmt, local string) time.Time {
+	const layout = "2006-01-02 15:04:05"
+	if t, err := time.Parse(layout, gmt); err == nil && t.Year() > 1 {
+		return t
+	}
+	if t, err := time.ParseInLocation(layout, local, time.Local); err == nil && t.Year() > 1 {
+		return t
+	}
+	return time.Time{}
+}
+
+var (
+	// WordPress 上传目录中的文件地址，包括绝对地址和站内相对地址
+	wpUploadURLRe = regexp.MustCompile(`(?:(?:https?:)?//[^/\s"'()<>]+)?/wp-content/uploads/([^\s"'()<>?#\]]+)`)
+	// 常见的核心短代码，只保留其中的内容
+	wpShortcodeRe = regexp.MustCompile(`\[/?(?:caption|gallery|embed|audio|video|playlist)\b[^\]]*\]`)
+)
+
+// WordPress 媒体文件的重新链接结果
+type wxrMediaReport struct {
+	Referenced int      `json:"referenced"`
+	Relinked   int      `json:"relinked"`
+	Missing    []string `json:"missing"`
+}
+
+// 把文章中引用的 WordPress 媒体文件改为指向本站上传目录
+// mediaRoot 为 wp-content/uploads 的本地副本，为空或找不到文件时保留原地址
+type wxrMediaLinker struct {
+	mediaRoot string
+	copies    map[string]string // 源文件 -> 上传目录中的相对路径
+	seen      map[string]bool
+	report    wxrMediaReport
+}
+
+func newWXRMediaLinker(mediaRoot string) *wxrMediaLinker {
+	return &wxrMediaLinker{
+		mediaRoot: mediaRoot,
+		copies:    make(map[string]string),
+		seen:      make(map[string]bool),
+		report:    wxrMediaReport{Missing: []string{}},
+	}
+}
+
+func (l *wxrMediaLinker) relink(s string) string {
+	return wpUploadURLRe.ReplaceAllStringFunc(s, func(match string) string {
+		raw := wpUploadURLRe.FindStringSubmatch(match)[1]
+		if unescaped, err := url.PathUnescape(raw); err == nil {
+			raw = unescaped
+		}
+		rel := safeRelPath(raw)
+		if rel == "" {
+			return match
+		}
+
+		firstSeen := !l.seen[rel]
+		l.seen[rel] = true
+		if firstSeen {
+			l.report.Referenced++
+		}
+
+		if l.mediaRoot != "" {
+			src := filepath.Join(l.mediaRoot, filepath.FromSlash(rel))
+			if info, err := os.Stat(src); err == nil && info.Mode().IsRegular() {
+				target := "wordpress/" + rel
+				if firstSeen {
+					l.copies[src] = target
+					l.report.Relinked++
+				}
+				return "/uploads/" + target
+			}
+		}
+		if firstSeen {
+			l.report.Missing = append(l.report.Missing, rel)
+		}
+		return match
+	})
+}
+
+// 把媒体文件复制到上传目录
+func (l *wxrMediaLinker) copyFiles() (int, error) {
+	copied := 0
+	for src, target := range l.copies {
+		dst := filepath.Join(config.AppConfig.UploadPath, filepath.FromSlash(target))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return copied, fmt.Errorf("创建上传目录失败: %w", err)
+		}
+		if err := copyFile(src, dst); err != nil {
+			return copied, fmt.Errorf("复制媒体文件 %s 失败: %w", target, err)
+		}
+		copied++
+	}
+	return copied, nil
+}
+
+// WordPress 内容转换为 Markdown
+func convertWXRContent(content string, media *wxrMediaLinker) string {
+	content = wpShortcodeRe.ReplaceAllString(content, "")
+	return utils.HTMLToMarkdown(media.relink(content))
+}
+
+// WXR 转换统计
+type wxrSummary struct {
+	Posts   int            `json:"posts"`
+	Pages   int            `json:"pages"`
+	Skipped map[string]int `json:"skipped"` // 按状态或类型统计未导入的条目
+}
+
+// 把 WXR 转换为 BackupData，ID 为临时编号，导入时重新分配
+func convertWXR(doc *wxrDocument, media *wxrMediaLinker) (*BackupData, *wxrSummary) {
+	data := &BackupData{
+		Posts:      []models.Post{},
+		Tags:       []models.Tag{},
+		Users:      []models.User{},
+		PostLikes:  []models.PostLike{},
+		ExportedAt: time.Now(),
+		Version:    backupVersion,
+	}
+	summary := &wxrSummary{Skipped: map[string]int{}}
+
+	// 分类和标签都转换为标签，按名称去重，跳过默认的"未分类"
+	tagIDs := make(map[string]uint)
+	addTag := func(name, slug string) {
+		name = strings.TrimSpace(name)
+		if name == "" || slug == "uncategorized" {
+			return
+		}
+		if _, ok := tagIDs[name]; ok {
+			return
+		}
+		id := uint(len(data.Tags) + 1)
+		tagIDs[name] = id
+		data.Tags = append(data.Tags, models.Tag{ID: id, Name: name})
+	}
+	for _, category := range doc.Channel.Categories {
+		addTag(category.Name, category.Slug)
+	}
+	for _, tag := range doc.Channel.Tags {
+		addTag(tag.Name, tag.Slug)
+	}
+
+	// 作者转换为用户，导入后需要管理员重置密码才能登录
+	userIDs := make(map[string]uint)
+	addUser := func(login, email, displayName string) uint {
+		login = strings.TrimSpace(login)
+		if login == "" {
+			return 0
+		}
+		if id, ok := userIDs[login]; ok {
+			return id
+		}
+		id := uint(len(data.Users) + 1)
+		userIDs[login] = id
+		data.Users = append(data.Users, models.User{
+			ID:          id,
+			Username:    login,
+			Email:       email,
+			DisplayName: displayName,
+			UserType:    models.UserTypeRegular,
+			Status:      models.UserStatusActive,
+		})
+		return id
+	}
+	for _, author := range doc.Channel.Authors {
+		addUser(author.Login, author.Email, author.DisplayName)
+	}
+
+	// 附件ID -> 地址，用于文章封面
+	attachments := make(map[string]string)
+	for _, item := range doc.Channel.Items {
+		if item.PostType == "attachment" && item.AttachmentURL != "" {
+			attachments[fmt.Sprint(item.PostID)] = item.AttachmentURL
+		}
+	}
+
+	for _, item := range doc.Channel.Items {
+		if item.PostType != "post" && item.PostType != "page" {
+			summary.Skipped[item.PostType]++
+			continue
+		}
+
+		var published bool
+		switch item.Status {
+		case "publish":
+			published = true
+		case "draft", "pending", "private", "future":
+			published = false
+		default: // trash、auto-draft 等
+			summary.Skipped[item.Status]++
+			continue
+		}
+		if item.PostType == "page" {
+			summary.Pages++
+		} else {
+			summary.Posts++
+		}
+
+		var tags []models.Tag
+		for _, category := range item.Categories {
+			if category.Domain != "category" && category.Domain != "post_tag" {
+				continue
+			}
+			addTag(category.Name, category.Nicename)
+			if id, ok := tagIDs[strings.TrimSpace(category.Name)]; ok {
+				tags = append(tags, models.Tag{ID: id, Name: strings.TrimSpace(category.Name)})
+			}
+		}
+
+		title := strings.TrimSpace(item.Title)
+		if title == "" {
+			title = "(无标题)"
+		}
+
+		post := models.Post{
+			ID:        uint(len(data.Posts) + 1),
+			Title:     title,
+			Slug:      utils.Slugify(wxrSlug(item.PostName)),
+			Content:   convertWXRContent(item.encoded("content"), media),
+			Summary:   convertWXRContent(item.encoded("excerpt"), media),
+			Published: published,
+			AuthorID:  addUser(item.Creator, "", ""),
+			CreatedAt: parseWXRTime(item.PostDateGMT, item.PostDate),
+			UpdatedAt: parseWXRTime(item.ModifiedGMT, item.Modified),
+			Tags:      tags,
+		}
+		if thumbnail := attachments[item.meta("_thumbnail_id")]; thumbnail != "" {
+			post.CoverImage = media.relink(thumbnail)
+		}
+		data.Posts = append(data.Posts, post)
+	}
+
+	return data, summary
+}
+
+// 导入 WordPress 导出文件 (仅管理员可用)
+func ImportWordPress(c *gin.Context) {
+	// 验证管理员权限
+	if !isAdmin(c) {
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "获取上传文件失败: " + err.Error()})
+		return
+	}
+	defer file.Close()
+
+	if filepath.Ext(strings.ToLower(header.Filename)) != ".xml" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只支持 WordPress 导出的XML文件"})
+		return
+	}
+
+	// 媒体目录位于 IMPORT_PATH 下，为 wp-content/uploads 的副本
+	mediaRoot := ""
+	if dir := c.DefaultPostForm("media_dir", c.Query("media_dir")); dir != "" {
+		rel := safeRelPath(dir)
+		if rel == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的媒体目录"})
+			return
+		}
+		mediaRoot = filepath.Join(config.AppConfig.ImportPath, filepath.FromSlash(rel))
+		if info, err := os.Stat(mediaRoot); err != nil || !info.IsDir() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "媒体目录不存在: " + rel})
+			return
+		}
+	}
+
+	var doc wxrDocument
+	if err := xml.NewDecoder(file).Decode(&doc); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "解析WordPress导出文件失败: " + err.Error()})
+		return
+	}
+
+	media := newWXRMediaLinker(mediaRoot)
+	data, summary := convertWXR(&doc, media)
+
+	// ID 是转换时的临时编号，不支持保留
+	options := parseImportOptions(c)
+	options.PreserveIDs = false
+
+	info := gin.H{
+		"source":  "wordpress",
+		"site":    doc.Channel.Link,
+		"items":   summary,
+		"media":   media.report,
+		"authors": len(data.Users),
+	}
+
+	executeImport(c, data, options, info, func(_ *ImportReport, results map[string]int) error {
+		copied, err := media.copyFiles()
+		results["media"] = copied
+		return err
+	})
+}
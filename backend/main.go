@@ -6,7 +6,15 @@ import (
 	"blog-backend/models"
 	"blog-backend/routes"
 	"blog-backend/utils"
+	"context"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -15,8 +23,48 @@ import (
 func main() { // 初始化配置
 	config.InitConfig()
 
+	// 不带子命令时启动服务器
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		printUsage()
+		os.Exit(2)
+	}
+	if err := cmd.run(args); err != nil {
+		log.Fatalf("%s 失败: %v", name, err)
+	}
+}
+
+// 收到退出信号后等待进行中的请求完成的最长时间
+const shutdownTimeout = 30 * time.Second
+
+// 启动HTTP服务器
+func runServe(args []string) error {
 	// 初始化JWT
-	utils.InitJWT()
+	if err := utils.InitJWT(); err != nil {
+		log.Fatal("初始化JWT失败:", err)
+	}
+
+	// 收到SIGHUP时重新加载JWT密钥，用于无停机轮换
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if err := utils.InitJWT(); err != nil {
+				log.Printf("重新加载JWT密钥失败: %v", err)
+				continue
+			}
+			log.Println("JWT密钥已重新加载")
+		}
+	}()
+
+	// 收到退出信号后停止接收请求，等待进行中的请求和备份完成，再写入缓冲的浏览量
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
 	// 设置Gin模式
 	if config.AppConfig.Environment == "production" {
@@ -42,6 +90,23 @@ func main() { // 初始化配置
 	// 初始化管理员账户
 	controllers.InitAdmin()
 
+	// 为旧文章补充slug
+	controllers.BackfillPostSlugs()
+
+	// 定期删除宽限期已过的注销账户
+	controllers.StartAccountPurger(time.Hour)
+
+	// 按计划自动备份
+	if err := controllers.StartBackupScheduler(config.AppConfig.BackupSchedule); err != nil {
+		log.Fatal("启动自动备份失败:", err)
+	}
+
+	// 浏览量先在内存中累计，定期写入数据库
+	controllers.StartViewCounter()
+
+	// 定期重新计算文章热度
+	controllers.StartTrendingUpdater()
+
 	// 设置路由
 	routes.SetupRoutes(r)
 
@@ -51,7 +116,26 @@ func main() { // 初始化配置
 	log.Printf("数据库类型: %s", config.AppConfig.DBType)
 	log.Printf("环境: %s", config.AppConfig.Environment)
 
-	if err := r.Run(port); err != nil {
-		log.Fatal("启动服务器失败:", err)
+	server := &http.Server{Addr: port, Handler: r}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("启动服务器失败: %w", err)
+	case <-stop:
+	}
+
+	log.Println("正在关闭服务器...")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("等待请求完成超时: %v", err)
 	}
+	controllers.StopBackups()
+	controllers.FlushViews()
+	log.Println("服务器已关闭")
+	return nil
 }
@@ -35,7 +35,34 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// 每次请求都检查账户状态，暂停/封禁立即生效
+		var user models.User
+		if err := models.DB.First(&user, userID).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "用户不存在"})
+			c.Abort()
+			return
+		}
+
+		if user.IsBlocked() {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":      "账户已被暂停或封禁",
+				"status":     user.Status,
+				"reason":     user.StatusReason,
+				"expires_at": user.StatusExpiresAt,
+			})
+			c.Abort()
+			return
+		}
+
+		// 申请注销后旧token全部失效，宽限期内重新登录才会撤销注销
+		if user.DeletionRequestedAt != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "账户已申请注销，重新登录可撤销注销"})
+			c.Abort()
+			return
+		}
+
 		c.Set("userID", userID)
+		c.Set("user", user)
 		c.Next()
 	}
 }
@@ -50,14 +77,14 @@ func AdminMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		var user models.User
-		if err := models.DB.First(&user, userID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		user, ok := c.Get("user")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 			c.Abort()
 			return
 		}
 
-		if user.UserType != models.UserTypeAdmin {
+		if user.(models.User).UserType != models.UserTypeAdmin {
 			c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 			c.Abort()
 			return
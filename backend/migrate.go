@@ -0,0 +1,103 @@
+package main
+
+import (
+	"blog-backend/config"
+	"blog-backend/migrations"
+	"blog-backend/models"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"gorm.io/gorm"
+)
+
+const migrateUsage = `用法: blog-backend migrate <命令>
+
+  status          显示所有迁移及执行状态
+  up [版本]       执行未执行的迁移，指定版本时只迁移到该版本
+  down [数量]     回滚最近执行的迁移，默认回滚1个`
+
+// 数据库结构迁移命令，使用当前配置的数据库
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		fmt.Println(migrateUsage)
+		return errors.New("缺少迁移命令")
+	}
+
+	db, err := models.OpenDB(config.AppConfig.DBType, config.AppConfig.GetDSN())
+	if err != nil {
+		return fmt.Errorf("连接数据库失败: %w", err)
+	}
+
+	// 可选的数字参数
+	number := func(def int64) (int64, error) {
+		if len(args) < 2 {
+			return def, nil
+		}
+		n, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("无效的参数: %s", args[1])
+		}
+		return n, nil
+	}
+
+	switch args[0] {
+	case "status":
+		return printMigrationStatus(db)
+	case "up":
+		target, err := number(0)
+		if err != nil {
+			return err
+		}
+		executed, err := migrations.Up(db, target)
+		for _, m := range executed {
+			fmt.Printf("已执行: %d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(executed) == 0 {
+			fmt.Println("没有需要执行的迁移")
+		}
+		return err
+	case "down":
+		steps, err := number(1)
+		if err != nil {
+			return err
+		}
+		rolledBack, err := migrations.Down(db, int(steps))
+		for _, m := range rolledBack {
+			fmt.Printf("已回滚: %d_%s\n", m.Version, m.Name)
+		}
+		return err
+	default:
+		fmt.Println(migrateUsage)
+		return fmt.Errorf("未知的迁移命令: %s", args[0])
+	}
+}
+
+func printMigrationStatus(db *gorm.DB) error {
+	statuses, err := migrations.Status(db)
+	if err != nil {
+		return err
+	}
+	current, err := migrations.Current(db)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "版本\t名称\t状态")
+	for _, s := range statuses {
+		state := "待执行"
+		if s.AppliedAt != nil {
+			state = "已执行 " + s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		if !s.Known {
+			state += " (程序中不存在，数据库版本较新)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, state)
+	}
+	w.Flush()
+	fmt.Printf("当前版本: %d，程序支持的最新版本: %d\n", current, migrations.Latest())
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+// 跨数据库迁移命令，把当前数据库 (或 --from-* 指定的数据库) 的全部数据复制到目标数据库
+//
+//	blog-backend migrate-db --to-type postgres --to-dsn "host=... dbname=blog ..."
+func runMigrateDB(args []string) error {
+	fs := flag.NewFlagSet("migrate-db", flag.ExitOnError)
+	fromType := fs.String("from-type", config.AppConfig.DBType, "源数据库类型 (sqlite, mysql, postgres)，默认使用 DB_TYPE")
+	fromDSN := fs.String("from-dsn", config.AppConfig.GetDSN(), "源数据库连接字符串，SQLite 为文件路径，默认使用当前配置")
+	toType := fs.String("to-type", "", "目标数据库类型 (sqlite, mysql, postgres)")
+	toDSN := fs.String("to-dsn", "", "目标数据库连接字符串")
+	batchSize := fs.Int("batch", 500, "每批复制的行数")
+	force := fs.Bool("force", false, "目标数据库已有数据时清空后再复制")
+	verifyOnly := fs.Bool("verify-only", false, "只比较两边的行数和校验和，不复制数据")
+	fs.Parse(args)
+
+	if *toType == "" || *toDSN == "" {
+		fs.Usage()
+		return errors.New("必须指定 --to-type 和 --to-dsn")
+	}
+	if *fromType == *toType && *fromDSN == *toDSN {
+		return errors.New("源数据库和目标数据库相同")
+	}
+
+	src, err := models.OpenDB(*fromType, *fromDSN)
+	if err != nil {
+		return fmt.Errorf("连接源数据库失败: %w", err)
+	}
+	dst, err := models.OpenDB(*toType, *toDSN)
+	if err != nil {
+		return fmt.Errorf("连接目标数据库失败: %w", err)
+	}
+
+	var reports []models.TransferTableReport
+	if *verifyOnly {
+		reports, err = models.VerifyTransfer(src, dst, *batchSize)
+	} else {
+		fmt.Printf("正在从 %s 复制数据到 %s ...\n", *fromType, *toType)
+		reports, err = models.TransferDatabase(src, dst, models.TransferOptions{BatchSize: *batchSize, Force: *force})
+	}
+	printTransferReports(reports)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("所有表的行数和校验和一致")
+	return nil
+}
+
+func printTransferReports(reports []models.TransferTableReport) {
+	if len(reports) == 0 {
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "表\t复制\t源行数\t目标行数\t校验和\t结果")
+	for _, r := range reports {
+		result := "一致"
+		if !r.Verified {
+			result = "不一致"
+		}
+		sum := r.SourceSum
+		if len(sum) > 12 {
+			sum = sum[:12]
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s\n", r.Table, r.Copied, r.SourceRows, r.TargetRows, sum, result)
+	}
+	w.Flush()
+}
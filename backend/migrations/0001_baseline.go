@@ -0,0 +1,123 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 初始表结构，与引入版本化迁移之前 AutoMigrate 创建的结构一致
+// 这里的结构体是当时模型的副本，之后修改 models 中的模型不能影响本迁移，结构变化要写成新的迁移
+// 对已经由 AutoMigrate 创建过表的旧数据库，本迁移只补充缺少的列和索引
+
+type baselinePost struct {
+	ID         uint   `gorm:"primaryKey"`
+	Title      string `gorm:"not null"`
+	Slug       string `gorm:"index"`
+	Content    string `gorm:"type:text"`
+	Summary    string
+	CoverImage string
+	Published  bool `gorm:"default:false"`
+	ViewCount  int  `gorm:"default:0"`
+	Likes      int  `gorm:"default:0"`
+	AuthorID   uint `gorm:"index"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (baselinePost) TableName() string { return "posts" }
+
+type baselineTag struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"unique;not null"`
+	Color     string `gorm:"default:#3B82F6"`
+	CreatedAt time.Time
+}
+
+func (baselineTag) TableName() string { return "tags" }
+
+type baselineUser struct {
+	ID                  uint   `gorm:"primaryKey"`
+	Username            string `gorm:"unique;not null"`
+	Password            string `gorm:"not null"`
+	Email               string
+	EmailVerified       bool `gorm:"default:false"`
+	Avatar              string
+	DisplayName         string
+	Bio                 string `gorm:"type:text"`
+	Website             string
+	UserType            string `gorm:"default:user"`
+	CreatedAt           time.Time
+	Status              string `gorm:"default:active;index"`
+	StatusReason        string
+	StatusExpiresAt     *time.Time
+	DeletionRequestedAt *time.Time
+	PendingEmail        string
+	EmailToken          string `gorm:"index"`
+	EmailTokenExpiresAt *time.Time
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+// 文章和标签的关联表，原先由 many2many 自动创建
+type baselinePostTag struct {
+	TagID  uint         `gorm:"primaryKey"`
+	PostID uint         `gorm:"primaryKey"`
+	Tag    baselineTag  `gorm:"foreignKey:TagID"`
+	Post   baselinePost `gorm:"foreignKey:PostID"`
+}
+
+func (baselinePostTag) TableName() string { return "post_tags" }
+
+type baselinePostLike struct {
+	ID     uint         `gorm:"primaryKey"`
+	UserID uint         `gorm:"not null;index"`
+	PostID uint         `gorm:"not null;index"`
+	User   baselineUser `gorm:"foreignKey:UserID"`
+	Post   baselinePost `gorm:"foreignKey:PostID"`
+}
+
+func (baselinePostLike) TableName() string { return "post_likes" }
+
+type baselineInvitation struct {
+	ID        uint   `gorm:"primaryKey"`
+	Code      string `gorm:"uniqueIndex;not null"`
+	Email     string
+	CreatedBy uint
+	ExpiresAt *time.Time
+	UsedBy    *uint
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+func (baselineInvitation) TableName() string { return "invitations" }
+
+type baselineBackup struct {
+	ID         uint   `gorm:"primaryKey"`
+	Filename   string `gorm:"not null"`
+	Format     string
+	Encrypted  bool
+	Signed     bool
+	Trigger    string
+	Status     string `gorm:"index"`
+	Size       int64
+	Checksum   string
+	Error      string
+	StartedAt  time.Time `gorm:"index"`
+	FinishedAt *time.Time
+}
+
+func (baselineBackup) TableName() string { return "backups" }
+
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "baseline",
+		Up: Step{Func: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&baselinePost{}, &baselineTag{}, &baselinePostTag{}, &baselineUser{},
+				&baselinePostLike{}, &baselineInvitation{}, &baselineBackup{})
+		}},
+		// 回滚初始结构等于删除所有数据，不提供
+	})
+}
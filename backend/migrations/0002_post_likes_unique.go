@@ -0,0 +1,26 @@
+package migrations
+
+// 点赞记录增加 (user_id, post_id) 唯一索引，防止并发点赞写入重复记录
+// 建索引前先删除已有的重复点赞 (保留最早的一条)，并按点赞记录重新计算文章点赞数
+func init() {
+	// MySQL 不允许在子查询中直接引用要删除的表，多包一层派生表
+	dedupe := []string{
+		`DELETE FROM post_likes WHERE id NOT IN (
+			SELECT id FROM (SELECT MIN(id) AS id FROM post_likes GROUP BY user_id, post_id) AS keep_likes)`,
+		`UPDATE posts SET likes = (SELECT COUNT(*) FROM post_likes WHERE post_likes.post_id = posts.id)
+			WHERE likes <> (SELECT COUNT(*) FROM post_likes WHERE post_likes.post_id = posts.id)`,
+	}
+	create := "CREATE UNIQUE INDEX idx_post_likes_user_post ON post_likes (user_id, post_id)"
+
+	register(Migration{
+		Version: 2,
+		Name:    "post_likes_unique",
+		Up: Step{SQL: map[string][]string{
+			"": append(dedupe, create),
+		}},
+		Down: Step{SQL: map[string][]string{
+			"mysql": {"DROP INDEX idx_post_likes_user_post ON post_likes"},
+			"":      {"DROP INDEX idx_post_likes_user_post"},
+		}},
+	})
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// 文章每日统计表和来源统计表
+
+type postDailyStatV3 struct {
+	PostID   uint   `gorm:"primaryKey;autoIncrement:false"`
+	Day      string `gorm:"primaryKey;size:10;index"`
+	Views    int64  `gorm:"not null;default:0"`
+	Visitors int64  `gorm:"not null;default:0"`
+	Likes    int64  `gorm:"not null;default:0"`
+	Unlikes  int64  `gorm:"not null;default:0"`
+}
+
+func (postDailyStatV3) TableName() string { return "post_daily_stats" }
+
+type postReferrerStatV3 struct {
+	PostID uint   `gorm:"primaryKey;autoIncrement:false"`
+	Day    string `gorm:"primaryKey;size:10;index"`
+	Domain string `gorm:"primaryKey;size:255"`
+	Views  int64  `gorm:"not null;default:0"`
+}
+
+func (postReferrerStatV3) TableName() string { return "post_referrer_stats" }
+
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "post_stats",
+		Up: Step{Func: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&postDailyStatV3{}, &postReferrerStatV3{})
+		}},
+		Down: Step{Func: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&postReferrerStatV3{}, &postDailyStatV3{})
+		}},
+	})
+}
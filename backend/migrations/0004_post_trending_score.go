@@ -0,0 +1,35 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// 文章增加热度分数列，由后台任务定期计算，用于热门排序
+
+type postTrendingV4 struct {
+	TrendingScore float64 `gorm:"not null;default:0;index"`
+}
+
+func (postTrendingV4) TableName() string { return "posts" }
+
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "post_trending_score",
+		Up: Step{Func: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&postTrendingV4{}, "TrendingScore"); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&postTrendingV4{}, "TrendingScore")
+		}},
+		// SQLite 下 gorm 的 DropColumn 会重建表并丢失其他索引，直接用 DROP COLUMN (SQLite 3.35+)
+		Down: Step{SQL: map[string][]string{
+			"mysql": {
+				"DROP INDEX idx_posts_trending_score ON posts",
+				"ALTER TABLE posts DROP COLUMN trending_score",
+			},
+			"": {
+				"DROP INDEX idx_posts_trending_score",
+				"ALTER TABLE posts DROP COLUMN trending_score",
+			},
+		}},
+	})
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 系列文章表和系列成员表
+
+type seriesV5 struct {
+	ID          uint   `gorm:"primaryKey"`
+	Title       string `gorm:"not null"`
+	Slug        string `gorm:"uniqueIndex;not null"`
+	Description string `gorm:"type:text"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (seriesV5) TableName() string { return "series" }
+
+type seriesPostV5 struct {
+	SeriesID uint `gorm:"primaryKey;autoIncrement:false"`
+	PostID   uint `gorm:"primaryKey;autoIncrement:false;uniqueIndex"`
+	Position int  `gorm:"not null"`
+}
+
+func (seriesPostV5) TableName() string { return "series_posts" }
+
+func init() {
+	register(Migration{
+		Version: 5,
+		Name:    "series",
+		Up: Step{Func: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&seriesV5{}, &seriesPostV5{})
+		}},
+		Down: Step{Func: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&seriesPostV5{}, &seriesV5{})
+		}},
+	})
+}
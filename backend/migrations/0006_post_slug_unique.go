@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// 文章slug的普通索引改为唯一索引，防止并发创建或导入时写入重复的slug
+// 建索引前为空slug的文章生成 post-{id}，重复的slug保留最早的文章，其余依次追加 -2、-3…
+
+type postSlugV5 struct {
+	ID   uint   `gorm:"primaryKey"`
+	Slug string `gorm:"index"`
+}
+
+func (postSlugV5) TableName() string { return "posts" }
+
+type postSlugV6 struct {
+	ID   uint   `gorm:"primaryKey"`
+	Slug string `gorm:"uniqueIndex"`
+}
+
+func (postSlugV6) TableName() string { return "posts" }
+
+const postSlugIndex = "idx_posts_slug"
+
+// 修正空slug和重复的slug
+func dedupePostSlugs(tx *gorm.DB) error {
+	var posts []postSlugV6
+	if err := tx.Select("id, slug").Order("id").Find(&posts).Error; err != nil {
+		return err
+	}
+
+	used := make(map[string]bool)
+	var renamed []postSlugV6
+	for _, post := range posts {
+		if post.Slug == "" || used[post.Slug] {
+			renamed = append(renamed, post)
+			continue
+		}
+		used[post.Slug] = true
+	}
+
+	for _, post := range renamed {
+		base := post.Slug
+		if base == "" {
+			base = fmt.Sprintf("post-%d", post.ID)
+		}
+		slug := base
+		for i := 2; used[slug]; i++ {
+			slug = fmt.Sprintf("%s-%d", base, i)
+		}
+		used[slug] = true
+		if err := tx.Model(&postSlugV6{}).Where("id = ?", post.ID).UpdateColumn("slug", slug).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	register(Migration{
+		Version: 6,
+		Name:    "post_slug_unique",
+		Up: Step{Func: func(tx *gorm.DB) error {
+			if err := dedupePostSlugs(tx); err != nil {
+				return err
+			}
+			if tx.Migrator().HasIndex(&postSlugV5{}, postSlugIndex) {
+				if err := tx.Migrator().DropIndex(&postSlugV5{}, postSlugIndex); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().CreateIndex(&postSlugV6{}, postSlugIndex)
+		}},
+		Down: Step{Func: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&postSlugV6{}, postSlugIndex); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&postSlugV5{}, postSlugIndex)
+		}},
+	})
+}
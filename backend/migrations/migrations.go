@@ -0,0 +1,238 @@
+package migrations
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 迁移记录表，每执行一个版本写入一行
+const TableName = "schema_migrations"
+
+// 已执行的迁移版本
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return TableName
+}
+
+// 迁移的一个方向
+// SQL 按数据库类型 (sqlite、mysql、postgres) 提供语句，"" 为所有数据库通用；
+// 表结构变化难以用SQL统一表达时可以用 Func 通过 gorm 的 Migrator 实现
+type Step struct {
+	SQL  map[string][]string
+	Func func(tx *gorm.DB) error
+}
+
+func (s Step) empty() bool {
+	return s.Func == nil && s.SQL == nil
+}
+
+func (s Step) run(tx *gorm.DB) error {
+	if s.Func != nil {
+		return s.Func(tx)
+	}
+
+	dialect := tx.Dialector.Name()
+	statements, ok := s.SQL[dialect]
+	if !ok {
+		statements, ok = s.SQL[""]
+	}
+	if !ok {
+		return fmt.Errorf("不支持 %s 数据库", dialect)
+	}
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 一个版本的迁移，Down 为空表示不能回滚
+type Migration struct {
+	Version int64
+	Name    string
+	Up      Step
+	Down    Step
+}
+
+var registry []Migration
+
+// 注册迁移，版本号必须唯一
+func register(m Migration) {
+	for _, existing := range registry {
+		if existing.Version == m.Version {
+			panic(fmt.Sprintf("迁移版本 %d 重复", m.Version))
+		}
+	}
+	registry = append(registry, m)
+	sort.Slice(registry, func(i, j int) bool { return registry[i].Version < registry[j].Version })
+}
+
+// 程序支持的最新版本
+func Latest() int64 {
+	if len(registry) == 0 {
+		return 0
+	}
+	return registry[len(registry)-1].Version
+}
+
+func find(version int64) (Migration, bool) {
+	for _, m := range registry {
+		if m.Version == version {
+			return m, true
+		}
+	}
+	return Migration{}, false
+}
+
+func ensureTable(db *gorm.DB) error {
+	return db.AutoMigrate(&schemaMigration{})
+}
+
+// 已执行的迁移，按版本排序
+func applied(db *gorm.DB) ([]schemaMigration, error) {
+	if err := ensureTable(db); err != nil {
+		return nil, fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+	var records []schemaMigration
+	err := db.Order("version").Find(&records).Error
+	return records, err
+}
+
+// 数据库当前的结构版本，未执行过迁移时为0
+func Current(db *gorm.DB) (int64, error) {
+	records, err := applied(db)
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+	return records[len(records)-1].Version, nil
+}
+
+// 检查数据库结构是否由更新的程序迁移过，是则拒绝继续
+func Check(db *gorm.DB) error {
+	current, err := Current(db)
+	if err != nil {
+		return err
+	}
+	if current > Latest() {
+		return fmt.Errorf("数据库结构版本 %d 高于程序支持的版本 %d，请升级程序后再启动", current, Latest())
+	}
+	return nil
+}
+
+// 执行单个迁移并记录，每个迁移在一个事务中进行
+// 注意 MySQL 的DDL语句会隐式提交，失败时可能需要手动修复
+func apply(db *gorm.DB, m Migration, up bool) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if up {
+			if err := m.Up.run(tx); err != nil {
+				return fmt.Errorf("执行迁移 %d_%s 失败: %w", m.Version, m.Name, err)
+			}
+			return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		}
+
+		if m.Down.empty() {
+			return fmt.Errorf("迁移 %d_%s 不能回滚", m.Version, m.Name)
+		}
+		if err := m.Down.run(tx); err != nil {
+			return fmt.Errorf("回滚迁移 %d_%s 失败: %w", m.Version, m.Name, err)
+		}
+		return tx.Delete(&schemaMigration{}, m.Version).Error
+	})
+}
+
+// 按顺序执行所有未执行且不高于 target 的迁移，target 为0时迁移到最新版本
+func Up(db *gorm.DB, target int64) ([]Migration, error) {
+	if err := Check(db); err != nil {
+		return nil, err
+	}
+	records, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[int64]bool, len(records))
+	for _, r := range records {
+		done[r.Version] = true
+	}
+
+	var executed []Migration
+	for _, m := range registry {
+		if done[m.Version] || (target > 0 && m.Version > target) {
+			continue
+		}
+		if err := apply(db, m, true); err != nil {
+			return executed, err
+		}
+		executed = append(executed, m)
+	}
+	return executed, nil
+}
+
+// 按倒序回滚最近执行的 steps 个迁移
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	if err := Check(db); err != nil {
+		return nil, err
+	}
+	records, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var rolledBack []Migration
+	for i := len(records) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		m, ok := find(records[i].Version)
+		if !ok {
+			return rolledBack, fmt.Errorf("未知的迁移版本 %d", records[i].Version)
+		}
+		if err := apply(db, m, false); err != nil {
+			return rolledBack, err
+		}
+		rolledBack = append(rolledBack, m)
+	}
+	return rolledBack, nil
+}
+
+// 迁移状态
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time // 为空表示待执行
+	Known     bool       // 数据库中有记录但程序中不存在时为false
+}
+
+// 列出所有迁移及其执行状态，包括数据库中存在但程序不认识的版本
+func Status(db *gorm.DB) ([]MigrationStatus, error) {
+	records, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]schemaMigration, len(records))
+	for _, r := range records {
+		byVersion[r.Version] = r
+	}
+
+	var statuses []MigrationStatus
+	for _, m := range registry {
+		s := MigrationStatus{Version: m.Version, Name: m.Name, Known: true}
+		if r, ok := byVersion[m.Version]; ok {
+			appliedAt := r.AppliedAt
+			s.AppliedAt = &appliedAt
+			delete(byVersion, m.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for _, r := range byVersion {
+		appliedAt := r.AppliedAt
+		statuses = append(statuses, MigrationStatus{Version: r.Version, Name: r.Name, AppliedAt: &appliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
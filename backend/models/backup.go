@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// 备份状态
+const (
+	BackupStatusRunning = "running"
+	BackupStatusSuccess = "success"
+	BackupStatusFailed  = "failed"
+)
+
+// 备份触发方式
+const (
+	BackupTriggerScheduled = "scheduled"
+	BackupTriggerManual    = "manual"
+)
+
+// Backup 自动或手动备份的运行记录，备份文件保存在 BACKUP_DIR 中
+type Backup struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Filename   string     `json:"filename" gorm:"not null"`
+	Format     string     `json:"format"`
+	Encrypted  bool       `json:"encrypted"`
+	Signed     bool       `json:"signed"`
+	Trigger    string     `json:"trigger"`
+	Status     string     `json:"status" gorm:"index"`
+	Size       int64      `json:"size"`
+	Checksum   string     `json:"checksum"` // SHA256
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at" gorm:"index"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Invitation 注册邀请码模型，每个邀请码只能使用一次
+type Invitation struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Code      string     `json:"code" gorm:"uniqueIndex;not null"`
+	Email     string     `json:"email"` // 为空表示不限制注册邮箱
+	CreatedBy uint       `json:"created_by"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	UsedBy    *uint      `json:"used_by"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// 邀请码当前是否可用
+func (i *Invitation) IsUsable() bool {
+	if i.UsedAt != nil || i.RevokedAt != nil {
+		return false
+	}
+	return i.ExpiresAt == nil || time.Now().Before(*i.ExpiresAt)
+}
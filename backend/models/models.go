@@ -1,6 +1,8 @@
 package models
 
 import (
+	"blog-backend/migrations"
+	"fmt"
 	"log"
 	"time"
 
@@ -14,17 +16,22 @@ var DB *gorm.DB
 
 // 博客文章模型
 type Post struct {
-	ID         uint      `json:"id" gorm:"primaryKey"`
-	Title      string    `json:"title" gorm:"not null"`
-	Content    string    `json:"content" gorm:"type:text"`
-	Summary    string    `json:"summary"`
-	CoverImage string    `json:"cover_image"`
-	Published  bool      `json:"published" gorm:"default:false"`
-	ViewCount  int       `json:"view_count" gorm:"default:0"`
-	Likes      int       `json:"likes" gorm:"default:0"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-	Tags       []Tag     `json:"tags" gorm:"many2many:post_tags;"`
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	Title         string    `json:"title" gorm:"not null"`
+	Slug          string    `json:"slug" gorm:"uniqueIndex"`
+	Content       string    `json:"content" gorm:"type:text"`
+	Summary       string    `json:"summary"`
+	CoverImage    string    `json:"cover_image"`
+	Published     bool      `json:"published" gorm:"default:false"`
+	ViewCount     int       `json:"view_count" gorm:"default:0"`
+	Likes         int       `json:"likes" gorm:"default:0"`
+	AuthorID      uint      `json:"author_id" gorm:"index"`
+	TrendingScore float64   `json:"trending_score" gorm:"not null;default:0;index"` // 热度分数，由后台任务定期计算
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Tags          []Tag     `json:"tags" gorm:"many2many:post_tags;"`
+
+	Series *PostSeriesInfo `json:"series,omitempty" gorm:"-"` // 所属系列，只在文章详情中返回
 }
 
 // 标签模型
@@ -42,15 +49,47 @@ const (
 	UserTypeRegular = "user"
 )
 
+// 用户状态常量
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended" // 暂停，通常带到期时间
+	UserStatusBanned    = "banned"    // 封禁，不设到期时间即为永久
+)
+
 // 用户模型
 type User struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Username  string    `json:"username" gorm:"unique;not null"`
-	Password  string    `json:"-" gorm:"not null"`
-	Email     string    `json:"email"`
-	Avatar    string    `json:"avatar"`
-	UserType  string    `json:"user_type" gorm:"default:user"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	Username      string    `json:"username" gorm:"unique;not null"`
+	Password      string    `json:"-" gorm:"not null"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified" gorm:"default:false"`
+	Avatar        string    `json:"avatar"`
+	DisplayName   string    `json:"display_name"`
+	Bio           string    `json:"bio" gorm:"type:text"`
+	Website       string    `json:"website"`
+	UserType      string    `json:"user_type" gorm:"default:user"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	// 账户状态，暂停/封禁时记录原因和到期时间
+	Status          string     `json:"status" gorm:"default:active;index"`
+	StatusReason    string     `json:"status_reason,omitempty"`
+	StatusExpiresAt *time.Time `json:"status_expires_at,omitempty"`
+
+	// 申请注销的时间，宽限期内登录会撤销注销
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at,omitempty"`
+
+	// 邮箱变更待验证信息
+	PendingEmail        string     `json:"pending_email,omitempty"`
+	EmailToken          string     `json:"-" gorm:"index"`
+	EmailTokenExpiresAt *time.Time `json:"-"`
+}
+
+// 账户当前是否被暂停或封禁（已过期的不算）
+func (u *User) IsBlocked() bool {
+	if u.Status != UserStatusSuspended && u.Status != UserStatusBanned {
+		return false
+	}
+	return u.StatusExpiresAt == nil || time.Now().Before(*u.StatusExpiresAt)
 }
 
 // 初始化数据库
@@ -58,9 +97,8 @@ func InitDB() {
 	InitDBWithConfig("sqlite", "blog.db")
 }
 
-// 使用配置初始化数据库
-func InitDBWithConfig(dbType, dsn string) {
-	var err error
+// 按数据库类型打开连接，不执行迁移
+func OpenDB(dbType, dsn string) (*gorm.DB, error) {
 	var dialector gorm.Dialector
 
 	switch dbType {
@@ -71,19 +109,39 @@ func InitDBWithConfig(dbType, dsn string) {
 	case "sqlite":
 		dialector = sqlite.Open(dsn)
 	default:
+		return nil, fmt.Errorf("不支持的数据库类型: %s", dbType)
+	}
+
+	return gorm.Open(dialector, &gorm.Config{})
+}
+
+// 执行所有未执行的结构迁移，数据库结构版本高于程序支持的版本时返回错误
+func MigrateSchema(db *gorm.DB) error {
+	executed, err := migrations.Up(db, 0)
+	for _, m := range executed {
+		log.Printf("已执行数据库迁移: %d_%s", m.Version, m.Name)
+	}
+	return err
+}
+
+// 使用配置初始化数据库
+func InitDBWithConfig(dbType, dsn string) {
+	var err error
+
+	if dbType != "mysql" && dbType != "postgres" && dbType != "sqlite" {
 		log.Printf("不支持的数据库类型: %s，使用默认SQLite", dbType)
-		dialector = sqlite.Open("blog.db")
+		dbType, dsn = "sqlite", "blog.db"
 	}
 
-	DB, err = gorm.Open(dialector, &gorm.Config{})
+	DB, err = OpenDB(dbType, dsn)
 	if err != nil {
 		panic("连接数据库失败: " + err.Error())
 	}
 
 	log.Printf("数据库连接成功: %s", dbType)
 
-	// 自动迁移
-	err = DB.AutoMigrate(&Post{}, &Tag{}, &User{}, &PostLike{})
+	// 执行结构迁移
+	err = MigrateSchema(DB)
 	if err != nil {
 		panic("数据库迁移失败: " + err.Error())
 	}
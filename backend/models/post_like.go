@@ -1,10 +1,11 @@
 package models
 
 // PostLike 用户点赞记录模型
+// 每个用户对一篇文章只能有一条点赞记录，由唯一索引 idx_post_likes_user_post 保证
 type PostLike struct {
 	ID     uint `json:"id" gorm:"primaryKey"`
-	UserID uint `json:"user_id" gorm:"not null;index"`
-	PostID uint `json:"post_id" gorm:"not null;index"`
+	UserID uint `json:"user_id" gorm:"not null;index;uniqueIndex:idx_post_likes_user_post,priority:1"`
+	PostID uint `json:"post_id" gorm:"not null;index;uniqueIndex:idx_post_likes_user_post,priority:2"`
 	User   User `json:"-" gorm:"foreignKey:UserID"`
 	Post   Post `json:"-" gorm:"foreignKey:PostID"`
 }
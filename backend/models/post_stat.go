@@ -0,0 +1,22 @@
+package models
+
+// 统计日期格式，按服务器本地时区划分
+const StatDayFormat = "2006-01-02"
+
+// PostDailyStat 文章每日统计
+type PostDailyStat struct {
+	PostID   uint   `json:"post_id" gorm:"primaryKey;autoIncrement:false"`
+	Day      string `json:"day" gorm:"primaryKey;size:10;index"`
+	Views    int64  `json:"views" gorm:"not null;default:0"`    // 浏览量，与文章浏览量的计数规则相同
+	Visitors int64  `json:"visitors" gorm:"not null;default:0"` // 当天的独立访客数
+	Likes    int64  `json:"likes" gorm:"not null;default:0"`    // 当天新增的点赞
+	Unlikes  int64  `json:"unlikes" gorm:"not null;default:0"`  // 当天取消的点赞
+}
+
+// PostReferrerStat 文章每日按来源域名统计的浏览量
+type PostReferrerStat struct {
+	PostID uint   `json:"post_id" gorm:"primaryKey;autoIncrement:false"`
+	Day    string `json:"day" gorm:"primaryKey;size:10;index"`
+	Domain string `json:"domain" gorm:"primaryKey;size:255"`
+	Views  int64  `json:"views" gorm:"not null;default:0"`
+}
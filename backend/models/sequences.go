@@ -0,0 +1,25 @@
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// 显式写入ID后同步自增序列
+// PostgreSQL 的序列不会随显式ID前进，需要手动设置；MySQL 和 SQLite 会自动调整
+func ResetSequences(db *gorm.DB, tables ...string) error {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	for _, table := range tables {
+		sql := fmt.Sprintf(
+			"SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE((SELECT MAX(id) FROM %s), 0) + 1, false)",
+			table, table)
+		if err := db.Exec(sql).Error; err != nil {
+			return fmt.Errorf("同步 %s 序列失败: %w", table, err)
+		}
+	}
+	return nil
+}
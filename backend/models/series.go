@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Series 系列文章，例如分多篇发布的教程
+type Series struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Title       string    `json:"title" gorm:"not null"`
+	Slug        string    `json:"slug" gorm:"uniqueIndex;not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SeriesPost 系列中的一篇文章，Position 从1开始，一篇文章只能属于一个系列
+type SeriesPost struct {
+	SeriesID uint `json:"series_id" gorm:"primaryKey;autoIncrement:false"`
+	PostID   uint `json:"post_id" gorm:"primaryKey;autoIncrement:false;uniqueIndex"`
+	Position int  `json:"position" gorm:"not null"`
+}
+
+// 文章详情中附带的系列信息
+type PostSeriesInfo struct {
+	ID       uint           `json:"id"`
+	Title    string         `json:"title"`
+	Slug     string         `json:"slug"`
+	Position int            `json:"position"` // 当前文章是第几篇，从1开始
+	Total    int            `json:"total"`
+	Previous *SeriesPartRef `json:"previous"`
+	Next     *SeriesPartRef `json:"next"`
+}
+
+// 系列中相邻文章的链接信息
+type SeriesPartRef struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+	Slug  string `json:"slug"`
+}
@@ -0,0 +1,266 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 文章和标签的关联表，由 many2many 自动创建
+type postTag struct {
+	PostID uint `gorm:"primaryKey"`
+	TagID  uint `gorm:"primaryKey"`
+}
+
+func (postTag) TableName() string {
+	return "post_tags"
+}
+
+// 一张表的复制和校验方法
+type transferTable struct {
+	name     string
+	hasID    bool
+	copy     func(src, dst *gorm.DB, batchSize int) (int64, error)
+	checksum func(db *gorm.DB, batchSize int, precision time.Duration) (int64, string, error)
+}
+
+func newTransferTable[T any](name, order string, hasID bool) transferTable {
+	return transferTable{
+		name:  name,
+		hasID: hasID,
+		copy: func(src, dst *gorm.DB, batchSize int) (int64, error) {
+			return copyRows[T](src, dst, order, batchSize)
+		},
+		checksum: func(db *gorm.DB, batchSize int, precision time.Duration) (int64, string, error) {
+			return checksumRows[T](db, order, batchSize, precision)
+		},
+	}
+}
+
+// 按外键依赖顺序排列，被引用的表在前
+var transferTables = []transferTable{
+	newTransferTable[User]("users", "id", true),
+	newTransferTable[Tag]("tags", "id", true),
+	newTransferTable[Post]("posts", "id", true),
+	newTransferTable[postTag]("post_tags", "post_id, tag_id", false),
+	newTransferTable[PostLike]("post_likes", "id", true),
+	newTransferTable[Invitation]("invitations", "id", true),
+	newTransferTable[Backup]("backups", "id", true),
+	newTransferTable[PostDailyStat]("post_daily_stats", "post_id, day", false),
+	newTransferTable[PostReferrerStat]("post_referrer_stats", "post_id, day, domain", false),
+	newTransferTable[Series]("series", "id", true),
+	newTransferTable[SeriesPost]("series_posts", "series_id, post_id", false),
+}
+
+// 分批读取源表写入目标表，保留原始ID
+// 按列名写入而不是直接创建结构体，零值字段才不会被 gorm 替换成模型的默认值
+func copyRows[T any](src, dst *gorm.DB, order string, batchSize int) (int64, error) {
+	stmt := &gorm.Statement{DB: dst}
+	if err := stmt.Parse(new(T)); err != nil {
+		return 0, err
+	}
+	sch := stmt.Schema
+
+	var total int64
+	for offset := 0; ; offset += batchSize {
+		var rows []T
+		if err := src.Order(order).Limit(batchSize).Offset(offset).Find(&rows).Error; err != nil {
+			return total, err
+		}
+		if len(rows) == 0 {
+			return total, nil
+		}
+
+		values := make([]map[string]interface{}, len(rows))
+		for i := range rows {
+			rv := reflect.ValueOf(&rows[i]).Elem()
+			values[i] = make(map[string]interface{}, len(sch.DBNames))
+			for _, name := range sch.DBNames {
+				values[i][name], _ = sch.FieldsByDBName[name].ValueOf(dst.Statement.Context, rv)
+			}
+		}
+		if err := dst.Table(sch.Table).Create(&values).Error; err != nil {
+			return total, err
+		}
+		total += int64(len(rows))
+	}
+}
+
+// 计算表中所有行的校验和
+// 时间统一为UTC并截断到 precision，忽略不同数据库时间精度的差异
+func checksumRows[T any](db *gorm.DB, order string, batchSize int, precision time.Duration) (int64, string, error) {
+	h := sha256.New()
+	var count int64
+	for offset := 0; ; offset += batchSize {
+		var rows []T
+		if err := db.Order(order).Limit(batchSize).Offset(offset).Find(&rows).Error; err != nil {
+			return count, "", err
+		}
+		if len(rows) == 0 {
+			return count, hex.EncodeToString(h.Sum(nil)), nil
+		}
+		for i := range rows {
+			writeRowChecksum(h, reflect.ValueOf(rows[i]), precision)
+		}
+		count += int64(len(rows))
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// 写入一行中所有列的值，跳过关联字段
+func writeRowChecksum(h interface{ Write([]byte) (int, error) }, v reflect.Value, precision time.Duration) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				fmt.Fprint(h, "NULL|")
+				continue
+			}
+			field = field.Elem()
+		}
+
+		switch {
+		case field.Type() == timeType:
+			t := field.Interface().(time.Time)
+			fmt.Fprintf(h, "%d|", t.UTC().Truncate(precision).UnixNano())
+		case field.Kind() == reflect.Struct, field.Kind() == reflect.Slice:
+			continue
+		default:
+			fmt.Fprintf(h, "%v|", field.Interface())
+		}
+	}
+	fmt.Fprint(h, "\n")
+}
+
+// 复制选项
+type TransferOptions struct {
+	BatchSize int  // 每批复制的行数
+	Force     bool // 目标库已有数据时先清空
+}
+
+// 单张表的复制和校验结果
+type TransferTableReport struct {
+	Table      string `json:"table"`
+	Copied     int64  `json:"copied"`
+	SourceRows int64  `json:"source_rows"`
+	TargetRows int64  `json:"target_rows"`
+	SourceSum  string `json:"source_checksum"`
+	TargetSum  string `json:"target_checksum"`
+	Verified   bool   `json:"verified"`
+}
+
+// 时间比较精度：MySQL 的 datetime(3) 只保留毫秒，其他数据库保留微秒
+func transferPrecision(dbs ...*gorm.DB) time.Duration {
+	for _, db := range dbs {
+		if db.Dialector.Name() == "mysql" {
+			return time.Millisecond
+		}
+	}
+	return time.Microsecond
+}
+
+// 目标库中已有数据的表
+func nonEmptyTables(db *gorm.DB) ([]string, error) {
+	var tables []string
+	for _, t := range transferTables {
+		var count int64
+		if err := db.Table(t.name).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			tables = append(tables, t.name)
+		}
+	}
+	return tables, nil
+}
+
+// 把源数据库的所有数据复制到目标数据库，保留ID并同步自增序列，完成后校验行数和校验和
+// 目标库的表结构会先自动创建；复制在一个事务中进行，失败时目标库保持不变
+func TransferDatabase(src, dst *gorm.DB, opts TransferOptions) ([]TransferTableReport, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+
+	if err := MigrateSchema(dst); err != nil {
+		return nil, fmt.Errorf("创建目标表结构失败: %w", err)
+	}
+
+	existing, err := nonEmptyTables(dst)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 && !opts.Force {
+		return nil, fmt.Errorf("目标数据库不为空 (%v)，确认覆盖请使用 --force", existing)
+	}
+
+	reports := make([]TransferTableReport, len(transferTables))
+	err = dst.Transaction(func(tx *gorm.DB) error {
+		// 先按依赖的相反顺序清空
+		for i := len(transferTables) - 1; i >= 0; i-- {
+			if err := tx.Exec("DELETE FROM " + transferTables[i].name).Error; err != nil {
+				return fmt.Errorf("清空 %s 失败: %w", transferTables[i].name, err)
+			}
+		}
+
+		var sequenced []string
+		for i, t := range transferTables {
+			copied, err := t.copy(src, tx, opts.BatchSize)
+			if err != nil {
+				return fmt.Errorf("复制 %s 失败 (已复制 %d 行): %w", t.name, copied, err)
+			}
+			reports[i] = TransferTableReport{Table: t.name, Copied: copied}
+			if t.hasID {
+				sequenced = append(sequenced, t.name)
+			}
+		}
+
+		// MySQL 和 SQLite 写入显式ID后会自动调整自增值，PostgreSQL 需要手动同步序列
+		return ResetSequences(tx, sequenced...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	verified, err := VerifyTransfer(src, dst, opts.BatchSize)
+	for i := range verified {
+		verified[i].Copied = reports[i].Copied
+	}
+	return verified, err
+}
+
+// 比较两边每张表的行数和校验和
+func VerifyTransfer(src, dst *gorm.DB, batchSize int) ([]TransferTableReport, error) {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	precision := transferPrecision(src, dst)
+
+	reports := make([]TransferTableReport, len(transferTables))
+	var mismatched []string
+	for i, t := range transferTables {
+		r := &reports[i]
+		r.Table = t.name
+
+		var err error
+		if r.SourceRows, r.SourceSum, err = t.checksum(src, batchSize, precision); err != nil {
+			return nil, fmt.Errorf("校验源表 %s 失败: %w", t.name, err)
+		}
+		if r.TargetRows, r.TargetSum, err = t.checksum(dst, batchSize, precision); err != nil {
+			return nil, fmt.Errorf("校验目标表 %s 失败: %w", t.name, err)
+		}
+		r.Verified = r.SourceRows == r.TargetRows && r.SourceSum == r.TargetSum
+		if !r.Verified {
+			mismatched = append(mismatched, t.name)
+		}
+	}
+
+	if len(mismatched) > 0 {
+		return reports, fmt.Errorf("校验失败，以下表的数据不一致: %v", mismatched)
+	}
+	return reports, nil
+}
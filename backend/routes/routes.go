@@ -12,35 +12,80 @@ func SetupRoutes(r *gin.Engine) {
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok", "message": "Server is running"})
 	})
+	// JWT公钥，供其他服务验证token
+	r.GET("/.well-known/jwks.json", controllers.GetJWKS)
 	api := r.Group("/api")
 	// 公开路由
 	api.POST("/auth/login", controllers.Login)
 	api.POST("/auth/register", controllers.Register)
+	api.GET("/auth/registration-mode", controllers.GetRegistrationMode)
+	api.GET("/auth/verify-email", controllers.VerifyEmail)
 	api.GET("/posts", controllers.GetPosts)
+	api.GET("/posts/trending", controllers.GetTrendingPosts)
 	api.GET("/posts/:id", controllers.GetPost)
 	api.GET("/posts/:id/like/check", controllers.CheckPostLike)
+	api.GET("/posts/:id/related", controllers.GetRelatedPosts)
+	api.GET("/series", controllers.GetSeriesList)
+	api.GET("/series/:slug", controllers.GetSeries)
 	api.GET("/tags", controllers.GetTags) // 标签列表公开访问
+	api.GET("/users/:username", controllers.GetUserProfile)
+	api.GET("/backup/schema", controllers.GetBackupSchema) // 备份格式定义，?version= 指定版本
 	auth := api.Group("/")
 	auth.Use(middleware.AuthMiddleware())
 	{
 		// 用户相关
 		auth.GET("/profile", controllers.GetProfile)
+		auth.PUT("/profile", controllers.UpdateProfile)
+		auth.DELETE("/profile", controllers.DeleteAccount)
+		auth.POST("/profile/avatar", controllers.UploadAvatar)
+		auth.GET("/profile/export", controllers.ExportMyData)
 		auth.POST("/change-password", controllers.ChangePassword)
 
 		// 点赞功能（需要身份验证）
-		auth.POST("/posts/:id/like", controllers.LikePost) // 管理员路由组
+		auth.POST("/posts/:id/like", controllers.LikePost)
+		auth.PUT("/posts/:id/like", controllers.PutPostLike)
+		auth.DELETE("/posts/:id/like", controllers.DeletePostLike)
+
+		// 管理员路由组
 		admin := auth.Group("/admin")
 		admin.Use(middleware.AdminMiddleware())
 		{
 			admin.POST("/change-user-password", controllers.AdminChangeUserPassword)
-			admin.GET("/users", controllers.GetAllUsers) // 数据备份和导入
+			admin.GET("/users", controllers.GetAllUsers)
+			admin.POST("/users/:id/suspend", controllers.SuspendUser)
+			admin.POST("/users/:id/ban", controllers.BanUser)
+			admin.POST("/users/:id/reinstate", controllers.ReinstateUser)
+			admin.PUT("/users/:id/role", controllers.UpdateUserRole)
+			admin.DELETE("/users/:id", controllers.DeleteUser)
+
+			// 注册邀请码
+			admin.GET("/invitations", controllers.GetInvitations)
+			admin.POST("/invitations", controllers.CreateInvitation)
+			admin.DELETE("/invitations/:id", controllers.RevokeInvitation)
+
+			// 数据备份和导入
 			admin.GET("/export", controllers.ExportAllData)
+			admin.GET("/export/archive", controllers.ExportSiteArchive)
+			admin.GET("/export/markdown", controllers.ExportMarkdown)
+			admin.POST("/export/markdown", controllers.ExportMarkdownToDir)
 			admin.POST("/import", controllers.ImportData)
+			admin.POST("/import/wordpress", controllers.ImportWordPress)
+			admin.POST("/import/markdown", controllers.ImportMarkdown)
 			admin.GET("/backup-db", controllers.BackupDatabase)
+			admin.GET("/backups", controllers.GetBackups)
+			admin.POST("/backups", controllers.CreateBackup)
+			admin.GET("/backups/:id/download", controllers.DownloadBackup)
+			admin.DELETE("/backups/:id", controllers.DeleteBackup)
 
 			// 数据库管理
 			admin.GET("/database/info", controllers.GetDatabaseInfo)
 			admin.POST("/database/clean", controllers.CleanDatabase)
+			admin.GET("/database/integrity", controllers.GetIntegrityReport)
+			admin.POST("/database/integrity/repair", controllers.RepairIntegrityIssues)
+
+			// 文章统计
+			admin.GET("/analytics", controllers.GetAnalytics)
+			admin.GET("/analytics/posts/:id", controllers.GetPostAnalytics)
 		}
 
 		// 文章管理
@@ -48,6 +93,11 @@ func SetupRoutes(r *gin.Engine) {
 		auth.PUT("/posts/:id", controllers.UpdatePost)
 		auth.DELETE("/posts/:id", controllers.DeletePost)
 
+		// 系列管理
+		auth.POST("/series", controllers.CreateSeries)
+		auth.PUT("/series/:id", controllers.UpdateSeries)
+		auth.DELETE("/series/:id", controllers.DeleteSeries)
+
 		// 标签管理
 		auth.POST("/tags", controllers.CreateTag)
 		auth.PUT("/tags/:id", controllers.UpdateTag)
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"image"
+	"image/jpeg"
+	"os"
+
+	// 注册GIF/PNG解码器
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+)
+
+// 居中裁剪为正方形，透明区域填充白色
+func CropSquare(src image.Image) image.Image {
+	b := src.Bounds()
+	size := b.Dx()
+	if b.Dy() < size {
+		size = b.Dy()
+	}
+	x0 := b.Min.X + (b.Dx()-size)/2
+	y0 := b.Min.Y + (b.Dy()-size)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, image.Pt(x0, y0), draw.Over)
+	return dst
+}
+
+// 缩放为 size x size
+func ResizeSquare(src image.Image, size int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+	return dst
+}
+
+// 保存为JPEG文件
+func SaveJPEG(img image.Image, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return jpeg.Encode(f, img, &jpeg.Options{Quality: 90})
+}
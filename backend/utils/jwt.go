@@ -3,28 +3,38 @@ package utils
 import (
 	"blog-backend/config"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
-var jwtSecret []byte
-
 // 初始化JWT密钥
-func InitJWT() {
-	if config.AppConfig != nil {
-		jwtSecret = []byte(config.AppConfig.JWTSecret)
-	} else {
-		jwtSecret = []byte("your-secret-key-change-in-production")
+// HS256 使用 JWT_SECRET；RS256/EdDSA 从 JWT_KEY_DIR 加载密钥文件
+func InitJWT() error {
+	if config.AppConfig == nil {
+		setKeyRing(newHMACKeyRing([]byte("your-secret-key-change-in-production")))
+		return nil
 	}
-}
 
-// 获取JWT密钥
-func getJWTSecret() []byte {
-	if len(jwtSecret) == 0 {
-		InitJWT()
+	cfg := config.AppConfig
+	switch cfg.JWTAlgorithm {
+	case "", "HS256":
+		if cfg.Environment == "production" && cfg.JWTSecret == config.DefaultJWTSecret {
+			return errors.New("生产环境禁止使用默认JWT密钥，请设置JWT_SECRET或改用非对称密钥")
+		}
+		setKeyRing(newHMACKeyRing([]byte(cfg.JWTSecret)))
+		return nil
+	case "RS256", "EdDSA":
+		ring, err := loadKeyRing(cfg.JWTAlgorithm, cfg.JWTKeyDir, cfg.JWTActiveKID)
+		if err != nil {
+			return err
+		}
+		setKeyRing(ring)
+		return nil
+	default:
+		return fmt.Errorf("不支持的JWT签名算法: %s", cfg.JWTAlgorithm)
 	}
-	return jwtSecret
 }
 
 type Claims struct {
@@ -34,6 +44,11 @@ type Claims struct {
 
 // 生成JWT token
 func GenerateToken(userID uint) (string, error) {
+	ring, err := getKeyRing()
+	if err != nil {
+		return "", err
+	}
+
 	claims := Claims{
 		UserID: userID,
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -42,14 +57,33 @@ func GenerateToken(userID uint) (string, error) {
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(getJWTSecret())
+	signer := ring.active
+	token := jwt.NewWithClaims(signer.method, claims)
+	if signer.kid != "" {
+		token.Header["kid"] = signer.kid
+	}
+	return token.SignedString(signer.signKey)
 }
 
 // 验证JWT token
 func ValidateToken(tokenString string) (uint, error) {
+	ring, err := getKeyRing()
+	if err != nil {
+		return 0, err
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return getJWTSecret(), nil
+		key := ring.active
+		// 带kid的token按kid查找，兼容轮换前签发的旧token
+		if kid, ok := token.Header["kid"].(string); ok && kid != "" {
+			if key = ring.keys[kid]; key == nil {
+				return nil, errors.New("未知的签名密钥")
+			}
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, errors.New("签名算法不匹配")
+		}
+		return key.verifyKey, nil
 	})
 
 	if err != nil {
@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// 单个签名/验证密钥
+type jwtKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   interface{} // 仅私钥文件有值，公钥文件只用于验证
+	verifyKey interface{}
+}
+
+// 当前生效的密钥集合
+type jwtKeyRing struct {
+	active *jwtKey
+	keys   map[string]*jwtKey
+}
+
+var (
+	keyRingMu sync.RWMutex
+	keyRing   *jwtKeyRing
+)
+
+func setKeyRing(ring *jwtKeyRing) {
+	keyRingMu.Lock()
+	keyRing = ring
+	keyRingMu.Unlock()
+}
+
+// 获取密钥集合，未初始化时自动初始化
+func getKeyRing() (*jwtKeyRing, error) {
+	keyRingMu.RLock()
+	ring := keyRing
+	keyRingMu.RUnlock()
+	if ring != nil {
+		return ring, nil
+	}
+
+	if err := InitJWT(); err != nil {
+		return nil, err
+	}
+	keyRingMu.RLock()
+	defer keyRingMu.RUnlock()
+	return keyRing, nil
+}
+
+func newHMACKeyRing(secret []byte) *jwtKeyRing {
+	key := &jwtKey{method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+	return &jwtKeyRing{active: key, keys: map[string]*jwtKey{}}
+}
+
+// 从目录加载密钥，文件名(去掉扩展名)作为kid
+// 私钥文件可签名也可验证；公钥文件只用于验证，便于轮换时保留旧密钥
+func loadKeyRing(algorithm, dir, activeKID string) (*jwtKeyRing, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	ring := &jwtKeyRing{keys: make(map[string]*jwtKey)}
+	var firstSigner *jwtKey
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		key, err := loadKeyFile(algorithm, file)
+		if err != nil {
+			return nil, fmt.Errorf("加载JWT密钥 %s 失败: %w", file, err)
+		}
+		key.kid = kid
+		ring.keys[kid] = key
+		if firstSigner == nil && key.signKey != nil {
+			firstSigner = key
+		}
+	}
+
+	if activeKID == "" {
+		ring.active = firstSigner
+	} else if key := ring.keys[activeKID]; key != nil && key.signKey != nil {
+		ring.active = key
+	}
+	if ring.active == nil {
+		return nil, fmt.Errorf("目录 %s 中没有可用于签名的 %s 私钥", dir, algorithm)
+	}
+	return ring, nil
+}
+
+func loadKeyFile(algorithm, file string) (*jwtKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("不是有效的PEM文件")
+	}
+
+	var private, public interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("不支持的PEM类型: %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if signer, ok := private.(crypto.Signer); ok {
+		public = signer.Public()
+	}
+
+	key := &jwtKey{signKey: private, verifyKey: public}
+	switch algorithm {
+	case "RS256":
+		if _, ok := public.(*rsa.PublicKey); !ok {
+			return nil, errors.New("RS256需要RSA密钥")
+		}
+		key.method = jwt.SigningMethodRS256
+	case "EdDSA":
+		if _, ok := public.(ed25519.PublicKey); !ok {
+			return nil, errors.New("EdDSA需要Ed25519密钥")
+		}
+		key.method = jwt.SigningMethodEdDSA
+	}
+	return key, nil
+}
+
+// JSON Web Key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JSON Web Key Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// 导出所有验证公钥，HS256对称密钥不会公开
+func GetJWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	ring, err := getKeyRing()
+	if err != nil {
+		return set
+	}
+
+	kids := make([]string, 0, len(ring.keys))
+	for kid := range ring.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	encode := base64.RawURLEncoding.EncodeToString
+	for _, kid := range kids {
+		key := ring.keys[kid]
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = encode(pub.N.Bytes())
+			jwk.E = encode(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = encode(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
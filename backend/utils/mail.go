@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"blog-backend/config"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"strings"
+)
+
+// 发送纯文本邮件，未配置SMTP时只记录日志（开发环境）
+func SendMail(to, subject, body string) error {
+	cfg := config.AppConfig
+	if cfg == nil || cfg.SMTPHost == "" {
+		log.Printf("[邮件] 收件人: %s 主题: %s\n%s", to, subject, body)
+		return nil
+	}
+
+	msg := strings.Join([]string{
+		"From: " + cfg.SMTPFrom,
+		"To: " + to,
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	var auth smtp.Auth
+	if cfg.SMTPUser != "" {
+		auth = smtp.PlainAuth("", cfg.SMTPUser, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+	addr := fmt.Sprintf("%s:%s", cfg.SMTPHost, cfg.SMTPPort)
+	return smtp.SendMail(addr, auth, cfg.SMTPFrom, []string{to}, []byte(msg))
+}
+
+// 生成随机令牌（十六进制）
+func RandomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
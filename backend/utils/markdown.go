@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	mdSpaceRe      = regexp.MustCompile(`[ \t\r\f]+`)
+	mdBlankLinesRe = regexp.MustCompile(`\n[ \t]*\n(?:[ \t]*\n)+`)
+	mdEscaper      = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`)
+)
+
+// 把HTML内容转换为Markdown
+// 支持常见的段落、标题、列表、引用、代码、链接、图片和表格，其他标签只保留文本
+// 文本中的换行保持原样，兼容不带 <p> 标签、以空行分段的内容
+func HTMLToMarkdown(src string) string {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(src), body)
+	if err != nil {
+		return src
+	}
+
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(markdownNode(n, 0))
+	}
+	return tidyMarkdown(b.String())
+}
+
+// 合并多余空行，去掉行尾空白（保留表示换行的两个空格）
+func tidyMarkdown(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, " \t")
+		if strings.HasSuffix(line, "  ") && trimmed != "" {
+			trimmed += "  "
+		}
+		lines[i] = trimmed
+	}
+	s = strings.Join(lines, "\n")
+	s = mdBlankLinesRe.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
+
+func markdownChildren(n *html.Node, depth int) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(markdownNode(c, depth))
+	}
+	return b.String()
+}
+
+// 取节点内的纯文本
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(nodeText(c))
+	}
+	return b.String()
+}
+
+func nodeAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// 行内内容不能跨行
+func inlineText(s string) string {
+	return strings.TrimSpace(strings.Join(strings.Fields(s), " "))
+}
+
+// 给多行内容的每一行加前缀，首行可以使用不同的前缀
+func prefixLines(s, first, rest string) string {
+	lines := strings.Split(strings.Trim(s, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			lines[i] = first + line
+		case line == "":
+			lines[i] = strings.TrimRight(rest, " ")
+		default:
+			lines[i] = rest + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// 用成对的标记包裹行内内容，空内容直接省略
+func wrapInline(s, mark string) string {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" {
+		return s
+	}
+	return mark + trimmed + mark
+}
+
+func markdownNode(n *html.Node, depth int) string {
+	switch n.Type {
+	case html.TextNode:
+		return mdEscaper.Replace(mdSpaceRe.ReplaceAllString(n.Data, " "))
+	case html.ElementNode:
+	default:
+		return markdownChildren(n, depth)
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Noscript:
+		return ""
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Figure, atom.Header, atom.Footer:
+		return "\n\n" + strings.TrimSpace(markdownChildren(n, depth)) + "\n\n"
+	case atom.Figcaption:
+		return "\n\n*" + inlineText(markdownChildren(n, depth)) + "*\n\n"
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		return "\n\n" + strings.Repeat("#", level) + " " + inlineText(markdownChildren(n, depth)) + "\n\n"
+	case atom.Br:
+		return "  \n"
+	case atom.Hr:
+		return "\n\n---\n\n"
+	case atom.Strong, atom.B:
+		return wrapInline(markdownChildren(n, depth), "**")
+	case atom.Em, atom.I:
+		return wrapInline(markdownChildren(n, depth), "*")
+	case atom.Del, atom.S, atom.Strike:
+		return wrapInline(markdownChildren(n, depth), "~~")
+	case atom.Code:
+		code := nodeText(n)
+		fence := "`"
+		if strings.Contains(code, "`") {
+			fence = "``"
+		}
+		return fence + code + fence
+	case atom.Pre:
+		return "\n\n```" + codeLanguage(n) + "\n" + strings.Trim(nodeText(n), "\n") + "\n```\n\n"
+	case atom.A:
+		text := inlineText(markdownChildren(n, depth))
+		href := nodeAttr(n, "href")
+		if href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			return text
+		}
+		if text == "" {
+			text = href
+		}
+		return "[" + text + "](" + href + ")"
+	case atom.Img:
+		src := nodeAttr(n, "src")
+		if src == "" {
+			return ""
+		}
+		return "![" + inlineText(nodeAttr(n, "alt")) + "](" + src + ")"
+	case atom.Iframe, atom.Video, atom.Audio:
+		src := nodeAttr(n, "src")
+		if src == "" {
+			return markdownChildren(n, depth)
+		}
+		return "\n\n[" + src + "](" + src + ")\n\n"
+	case atom.Blockquote:
+		inner := tidyMarkdown(markdownChildren(n, depth))
+		return "\n\n" + prefixLines(inner, "> ", "> ") + "\n\n"
+	case atom.Ul, atom.Ol:
+		return "\n\n" + markdownList(n, depth) + "\n\n"
+	case atom.Table:
+		return "\n\n" + markdownTable(n) + "\n\n"
+	}
+	return markdownChildren(n, depth)
+}
+
+// <pre class="language-go"> 或 <pre><code class="language-go">
+func codeLanguage(n *html.Node) string {
+	classes := nodeAttr(n, "class")
+	if code := n.FirstChild; code != nil && code.DataAtom == atom.Code {
+		classes += " " + nodeAttr(code, "class")
+	}
+	for _, class := range strings.Fields(classes) {
+		if lang, ok := strings.CutPrefix(class, "language-"); ok {
+			return lang
+		}
+		if lang, ok := strings.CutPrefix(class, "lang-"); ok {
+			return lang
+		}
+	}
+	return ""
+}
+
+func markdownList(n *html.Node, depth int) string {
+	var items []string
+	index := 1
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = fmt.Sprintf("%d. ", index)
+			index++
+		}
+		content := tidyMarkdown(markdownChildren(li, depth+1))
+		items = append(items, prefixLines(content, marker, strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+func markdownTable(n *html.Node) string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			if c.DataAtom != atom.Tr {
+				walk(c)
+				continue
+			}
+			var cells []string
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+					text := inlineText(markdownChildren(cell, 0))
+					cells = append(cells, strings.ReplaceAll(text, "|", `\|`))
+				}
+			}
+			rows = append(rows, cells)
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+
+	var b strings.Builder
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// slug 允许的最大长度
+const maxSlugLength = 80
+
+var slugInvalidRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// 根据标题生成URL友好的slug，只保留小写字母和数字，其余字符替换为连字符
+// 标题中没有可用字符时（例如纯中文标题）返回空字符串
+func Slugify(s string) string {
+	slug := strings.Trim(slugInvalidRe.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+	return slug
+}
@@ -12,6 +12,7 @@ import AdminDashboard from './pages/AdminDashboard';
 import PostEditor from './pages/PostEditor';
 import TagManager from './pages/TagManager';
 import TagsPage from './pages/TagsPage';
+import VerifyEmail from './pages/VerifyEmail';
 
 // 保护路由组件
 const ProtectedRoute: React.FC<{ children: React.ReactNode }> = ({ children }) => {
@@ -38,6 +39,7 @@ const App: React.FC = () => {
             <Route path="/posts" element={<PostList />} />
             <Route path="/posts/:id" element={<PostDetail />} />
             <Route path="/tags" element={<TagsPage />} />
+            <Route path="/verify-email" element={<VerifyEmail />} />
             <Route path="/admin/login" element={<AdminLogin />} />
               {/* 管理员路由 */}
             <Route 
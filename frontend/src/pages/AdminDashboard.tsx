@@ -31,8 +31,8 @@ const AdminDashboard: React.FC = () => {
 
   const fetchUsers = async () => {
     try {
-      const response = await authAPI.getAllUsers();
-      setUsers(response.data || []);
+      const response = await authAPI.getAllUsers({ limit: 100 });
+      setUsers(response.data.users || []);
     } catch (error) {
       console.error('获取用户列表失败:', error);
     }
@@ -44,13 +44,13 @@ const AdminDashboard: React.FC = () => {
         postsAPI.getPosts({ published: '' }),
         postsAPI.getPosts({ published: 'true' }),
         tagsAPI.getTags(),
-        authAPI.getAllUsers(),
+        authAPI.getAllUsers({ limit: 100 }),
       ]);
 
       const allPostsData = allPosts.data.posts;
       setPosts(allPostsData);
       setTags(tagsResponse.data || []);
-      setUsers(usersResponse.data || []);
+      setUsers(usersResponse.data.users || []);
       
       const totalViews = allPostsData.reduce((sum: number, post: Post) => sum + (post.view_count || 0), 0);
       
@@ -60,7 +60,7 @@ const AdminDashboard: React.FC = () => {
         draftPosts: allPostsData.filter((post: Post) => !post.published).length,
         totalTags: (tagsResponse.data || []).length,
         totalViews: totalViews,
-        totalUsers: usersResponse.data?.length || 0,
+        totalUsers: usersResponse.data.total || 0,
       });
     } catch (error) {
       console.error('获取数据失败:', error);
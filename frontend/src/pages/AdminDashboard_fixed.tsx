@@ -42,8 +42,8 @@ const AdminDashboard: React.FC = () => {
 
   const fetchUsers = async () => {
     try {
-      const response = await authAPI.getAllUsers();
-      setUsers(response.data || []);
+      const response = await authAPI.getAllUsers({ limit: 100 });
+      setUsers(response.data.users || []);
     } catch (error) {
       console.error('获取用户列表失败:', error);
     }
@@ -0,0 +1,68 @@
+import React, { useEffect, useRef, useState } from 'react';
+import { Link, useSearchParams } from 'react-router-dom';
+import { authAPI } from '../utils/api';
+import Header from '../components/Header';
+import Footer from '../components/Footer';
+
+// 邮件中的验证链接打开此页面，由页面调用验证接口
+const VerifyEmail: React.FC = () => {
+  const [searchParams] = useSearchParams();
+  const [status, setStatus] = useState<'loading' | 'success' | 'error'>('loading');
+  const [message, setMessage] = useState('');
+  // 令牌只能使用一次，避免开发模式下重复请求
+  const requested = useRef(false);
+
+  useEffect(() => {
+    if (requested.current) return;
+    requested.current = true;
+
+    const token = searchParams.get('token');
+    if (!token) {
+      setStatus('error');
+      setMessage('缺少验证令牌');
+      return;
+    }
+
+    authAPI.verifyEmail(token)
+      .then((response) => {
+        setStatus('success');
+        setMessage(`邮箱 ${response.data.email} 验证成功`);
+      })
+      .catch((error) => {
+        setStatus('error');
+        setMessage(error.response?.data?.error || '验证邮箱失败，请稍后重试');
+      });
+  }, [searchParams]);
+
+  return (
+    <div className="min-h-screen bg-gray-50 flex flex-col">
+      <Header />
+
+      <div className="flex-1 flex flex-col justify-center py-12 sm:px-6 lg:px-8">
+        <div className="sm:mx-auto sm:w-full sm:max-w-md">
+          <div className="bg-white py-8 px-4 shadow sm:rounded-lg sm:px-10 text-center">
+            <h2 className="text-2xl font-extrabold text-gray-900 mb-4">邮箱验证</h2>
+            {status === 'loading' && <p className="text-gray-600">正在验证...</p>}
+            {status === 'success' && (
+              <div className="bg-green-50 border border-green-200 text-green-700 px-4 py-3 rounded-md">
+                {message}
+              </div>
+            )}
+            {status === 'error' && (
+              <div className="bg-red-50 border border-red-200 text-red-600 px-4 py-3 rounded-md">
+                {message}
+              </div>
+            )}
+            <Link to="/" className="inline-block mt-6 text-blue-600 hover:text-blue-700">
+              返回首页
+            </Link>
+          </div>
+        </div>
+      </div>
+
+      <Footer />
+    </div>
+  );
+};
+
+export default VerifyEmail;
@@ -1,6 +1,7 @@
 export interface Post {
   id: number;
   title: string;
+  slug: string;
   content: string;
   summary: string;
   cover_image: string;
@@ -10,6 +11,23 @@ export interface Post {
   created_at: string;
   updated_at: string;
   tags: Tag[];
+  series?: PostSeriesInfo;
+}
+
+export interface SeriesPartRef {
+  id: number;
+  title: string;
+  slug: string;
+}
+
+export interface PostSeriesInfo {
+  id: number;
+  title: string;
+  slug: string;
+  position: number;
+  total: number;
+  previous: SeriesPartRef | null;
+  next: SeriesPartRef | null;
 }
 
 export interface Tag {
@@ -25,9 +43,19 @@ export interface User {
   email: string;
   avatar: string;
   user_type: string;
+  status: string;
+  status_reason?: string;
+  status_expires_at?: string;
   created_at: string;
 }
 
+export interface UsersResponse {
+  users: User[];
+  total: number;
+  page: number;
+  limit: number;
+}
+
 export interface LoginData {
   username: string;
   password: string;
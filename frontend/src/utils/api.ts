@@ -1,5 +1,5 @@
 import axios from 'axios';
-import { Post, PostsResponse, CreatePostData, Tag, LoginData, User, UploadResponse } from '../types';
+import { Post, PostsResponse, CreatePostData, Tag, LoginData, User, UsersResponse, UploadResponse } from '../types';
 
 const API_BASE_URL = process.env.REACT_APP_API_URL || 'http://localhost:8080/api';
 
@@ -28,6 +28,17 @@ api.interceptors.response.use(
   }
 );
 
+// 单页应用中 document.referrer 在整个会话里保持为进入站点时的来源，
+// 只有直接从外部打开的第一个页面才算该来源带来的访问，站内跳转不再带上
+let landingReferrer = document.referrer;
+const landingPath = window.location.pathname;
+
+const takeLandingReferrer = () => {
+  const ref = window.location.pathname === landingPath ? landingReferrer : '';
+  landingReferrer = '';
+  return ref;
+};
+
 // 认证相关
 export const authAPI = {
   login: (data: LoginData) => api.post<{ token: string; user: User }>('/auth/login', data),
@@ -38,13 +49,27 @@ export const authAPI = {
   adminChangeUserPassword: (data: { user_id: number; new_password: string }) => 
     api.post<{ message: string }>('/admin/change-user-password', data),
   getProfile: () => api.get<User>('/profile'),
-  getAllUsers: () => api.get<User[]>('/admin/users'),
+  verifyEmail: (token: string) =>
+    api.get<{ message: string; email: string }>('/auth/verify-email', { params: { token } }),
+  getAllUsers: (params?: { page?: number; limit?: number; search?: string; user_type?: string; status?: string }) =>
+    api.get<UsersResponse>('/admin/users', { params }),
+  suspendUser: (id: number, data: { reason?: string; expires_at?: string }) =>
+    api.post<User>(`/admin/users/${id}/suspend`, data),
+  banUser: (id: number, data: { reason?: string; expires_at?: string }) =>
+    api.post<User>(`/admin/users/${id}/ban`, data),
+  reinstateUser: (id: number) => api.post<User>(`/admin/users/${id}/reinstate`),
+  updateUserRole: (id: number, user_type: string) => api.put<User>(`/admin/users/${id}/role`, { user_type }),
+  deleteUser: (id: number) => api.delete(`/admin/users/${id}`),
 };
 
 // 博客文章相关
 export const postsAPI = {  getPosts: (params?: { page?: number; limit?: number; search?: string; tag?: string; published?: string; sort_by?: string }) =>
     api.get<PostsResponse>('/posts', { params }),
-  getPost: (id: number) => api.get<Post>(`/posts/${id}`),
+  // 带上外部来源页面，用于文章来源统计
+  getPost: (id: number) => {
+    const ref = takeLandingReferrer();
+    return api.get<Post>(`/posts/${id}`, { params: ref ? { ref } : undefined });
+  },
   createPost: (data: CreatePostData) => api.post<Post>('/posts', data),
   updatePost: (id: number, data: Partial<CreatePostData>) => api.put<Post>(`/posts/${id}`, data),
   deletePost: (id: number) => api.delete(`/posts/${id}`),
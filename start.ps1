@@ -8,7 +8,7 @@ if ($backendRunning) {
     Write-Host "✓ 后端服务器已在运行 (端口 8080)" -ForegroundColor Green
 } else {
     Write-Host "启动后端服务器..." -ForegroundColor Yellow
-    Start-Process powershell -ArgumentList "-NoExit", "-Command", "Set-Location 'c:\Users\leo\mysite\backend'; go run main.go" -WindowStyle Normal
+    Start-Process powershell -ArgumentList "-NoExit", "-Command", "Set-Location 'c:\Users\leo\mysite\backend'; go run ." -WindowStyle Normal
     Write-Host "✓ 后端服务器启动中..." -ForegroundColor Green
     Start-Sleep -Seconds 3
 }
@@ -4,7 +4,7 @@ Write-Host "开始测试博客功能..." -ForegroundColor Green
 
 # 启动后端服务
 Write-Host "启动后端服务..." -ForegroundColor Yellow
-Start-Process powershell -ArgumentList "-NoExit", "-Command", "cd '$PSScriptRoot\backend'; go run main.go"
+Start-Process powershell -ArgumentList "-NoExit", "-Command", "cd '$PSScriptRoot\backend'; go run ."
 
 # 等待后端启动
 Start-Sleep -Seconds 5
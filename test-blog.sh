@@ -5,7 +5,7 @@ echo "开始测试博客功能..."
 # 启动后端服务
 echo "启动后端服务..."
 cd backend
-go run main.go &
+go run . &
 BACKEND_PID=$!
 
 # 等待后端启动
@@ -0,0 +1,111 @@
+#!/bin/bash
+
+# 点赞并发测试脚本
+# 对运行中的服务器并发发送点赞请求，检查点赞数和点赞记录是否一致
+# 需要开放注册 (REGISTRATION_MODE=open)，会创建测试文章和 likes_test_* 用户
+
+set -e
+
+RED='\033[0;31m'
+GREEN='\033[0;32m'
+YELLOW='\033[1;33m'
+NC='\033[0m' # No Color
+
+BASE_URL=${1:-http://localhost:8080}
+ADMIN_USER=${2:-admin}
+ADMIN_PASS=${3:-admin123}
+USERS=${USERS:-10}          # 并发点赞的用户数
+REQUESTS=${REQUESTS:-50}    # 每轮并发请求数
+PARALLEL=${PARALLEL:-20}    # 同时进行的请求数
+
+FAILED=0
+
+# 从JSON中取出字段值 (数字或字符串)
+json_field() {
+    sed -n "s/.*\"$1\":\"\{0,1\}\([^,\"}]*\).*/\1/p" | head -n 1
+}
+
+login() {
+    curl -s -X POST "$BASE_URL/api/auth/login" \
+         -H "Content-Type: application/json" \
+         -d "{\"username\":\"$1\",\"password\":\"$2\"}" | json_field token
+}
+
+# 并发发送 count 个请求: hammer <方法> <令牌> <数量>
+hammer() {
+    seq "$2" | xargs -P "$PARALLEL" -I{} \
+        curl -s -o /dev/null -X "$1" -H "Authorization: Bearer $3" "$BASE_URL/api/posts/$POST_ID/like"
+}
+
+post_likes() {
+    curl -s "$BASE_URL/api/posts/$POST_ID" | json_field likes
+}
+
+expect() {
+    if [ "$2" = "$3" ]; then
+        echo -e "${GREEN}通过${NC} $1: $2"
+    else
+        echo -e "${RED}失败${NC} $1: 期望 $3，实际 $2"
+        FAILED=1
+    fi
+}
+
+echo -e "${YELLOW}登录管理员 $ADMIN_USER ...${NC}"
+ADMIN_TOKEN=$(login "$ADMIN_USER" "$ADMIN_PASS")
+if [ -z "$ADMIN_TOKEN" ]; then
+    echo -e "${RED}管理员登录失败${NC}"
+    exit 1
+fi
+
+POST_ID=$(curl -s -X POST "$BASE_URL/api/posts" \
+     -H "Authorization: Bearer $ADMIN_TOKEN" \
+     -H "Content-Type: application/json" \
+     -d '{"title":"点赞并发测试","content":"likes test","published":true}' | json_field id)
+echo "测试文章ID: $POST_ID"
+
+echo -e "${YELLOW}同一用户并发点赞 $REQUESTS 次...${NC}"
+hammer PUT "$REQUESTS" "$ADMIN_TOKEN"
+expect "重复点赞只计一次" "$(post_likes)" 1
+
+echo -e "${YELLOW}同一用户并发取消点赞 $REQUESTS 次...${NC}"
+hammer DELETE "$REQUESTS" "$ADMIN_TOKEN"
+expect "重复取消点赞不会变成负数" "$(post_likes)" 0
+
+echo -e "${YELLOW}注册 $USERS 个用户并同时点赞...${NC}"
+SUFFIX=$(date +%s)
+TOKENS=()
+for i in $(seq "$USERS"); do
+    name="likes_test_${SUFFIX}_$i"
+    curl -s -o /dev/null -X POST "$BASE_URL/api/auth/register" \
+         -H "Content-Type: application/json" \
+         -d "{\"username\":\"$name\",\"password\":\"password123\",\"email\":\"$name@example.com\"}"
+    TOKENS+=("$(login "$name" password123)")
+done
+for token in "${TOKENS[@]}"; do
+    hammer PUT 5 "$token" &
+done
+wait
+expect "每个用户计一次" "$(post_likes)" "$USERS"
+
+echo -e "${YELLOW}所有用户并发切换点赞 (POST)...${NC}"
+for token in "${TOKENS[@]}"; do
+    hammer POST 7 "$token" &
+done
+wait
+for token in "${TOKENS[@]}"; do
+    hammer DELETE 3 "$token" &
+done
+wait
+expect "全部取消后点赞数" "$(post_likes)" 0
+
+echo -e "${YELLOW}检查数据完整性...${NC}"
+ISSUES=$(curl -s -H "Authorization: Bearer $ADMIN_TOKEN" "$BASE_URL/api/admin/database/integrity" | json_field total_issues)
+expect "数据完整性问题" "$ISSUES" 0
+
+curl -s -o /dev/null -X DELETE -H "Authorization: Bearer $ADMIN_TOKEN" "$BASE_URL/api/posts/$POST_ID"
+
+if [ $FAILED -ne 0 ]; then
+    echo -e "${RED}测试失败${NC}"
+    exit 1
+fi
+echo -e "${GREEN}全部测试通过${NC}"